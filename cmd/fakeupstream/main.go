@@ -0,0 +1,53 @@
+// Command fakeupstream serves a stand-in for the mockapi.io books API on
+// /api/v1/books, with CRUD, pagination, ETags, latency and error injection.
+//
+//	go run ./cmd/fakeupstream -addr :4000 -latency 50ms -error-rate 0.05
+//	BOOKS_API_URL=http://localhost:4000/api/v1/books go run main.go
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"log"
+	"net/http"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/fakeupstream"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed books.json
+var defaultSeed []byte
+
+func main() {
+	addr := flag.String("addr", ":4000", "address to listen on")
+	seed := flag.String("seed", "", "JSON file with the initial books, a built-in catalog is used when empty")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	jitter := flag.Duration("jitter", 0, "random delay added on top of latency, up to this value")
+	errorRate := flag.Float64("error-rate", 0, "share of requests answered with a 500, between 0 and 1")
+	randomSeed := flag.Int64("random-seed", 1, "seed of the jitter and error injection")
+	flag.Parse()
+
+	var books []models.Book
+	var err error
+	if *seed == "" {
+		books, err = fakeupstream.DecodeSeed(defaultSeed)
+	} else {
+		books, err = fakeupstream.LoadSeed(*seed)
+	}
+	if err != nil {
+		log.Fatalf("Error loading seed books: %v", err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	server := fakeupstream.New(fakeupstream.Config{
+		Books:      books,
+		Latency:    *latency,
+		Jitter:     *jitter,
+		ErrorRate:  *errorRate,
+		RandomSeed: *randomSeed,
+	})
+
+	log.Printf("Serving %d books on %s%s", len(books), *addr, fakeupstream.BooksPath)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
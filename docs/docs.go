@@ -15,9 +15,107 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/anomalies": {
+            "get": {
+                "description": "Get the suspicious changes found when comparing each fetched catalog with the previous one: large price changes, decreasing units sold, vanished and duplicate IDs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get catalog anomalies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/providers.Anomaly"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/data-quality": {
+            "get": {
+                "description": "Validate the upstream catalog against the configured rules (empty names, missing authors, zero prices, duplicate IDs) and get the violations grouped by rule, with the offending IDs and the share of valid books as score, along with the records the upstream decoding had to coerce or drop",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the catalog data quality report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.DataQualityReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/mirrors": {
+            "get": {
+                "description": "Get, for BOOKS_API_URL and each of BOOKS_API_MIRRORS, how many hedged requests were sent, won, failed or cancelled, along with the latency of the successful ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get upstream mirror stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repositories.MirrorStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rate-limits": {
+            "get": {
+                "description": "Get, per upstream source, the configured token bucket and how many outgoing calls were allowed, throttled while waiting for a token or rejected",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get upstream rate limit stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repositories.RateLimitStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/books": {
             "get": {
-                "description": "Get a list of all available books",
+                "description": "Get a list of all available books, optionally filtered",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,6 +126,44 @@ const docTemplate = `{
                     "books"
                 ],
                 "summary": "Get all books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the book name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the author name",
+                        "name": "author_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum units sold",
+                        "name": "min_units_sold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum units sold",
+                        "name": "max_units_sold",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -37,13 +173,22 @@ const docTemplate = `{
                                 "$ref": "#/definitions/models.Book"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
         "/books/metrics": {
             "get": {
-                "description": "Get statistical metrics about books, optionally filtered by author",
+                "description": "Get statistical metrics about books, optionally computed over the books matching the same filters as /books. Every computed metric is written under its name, see /books/metrics/catalog",
                 "consumes": [
                     "application/json"
                 ],
@@ -60,6 +205,54 @@ const docTemplate = `{
                         "description": "Author name to filter metrics",
                         "name": "author",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated distribution statistics for units sold and price (min, max, mean, median, p90, p95, p99, stddev, iqr)",
+                        "name": "stats",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated metrics to compute, see /books/metrics/catalog",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the book name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the author name",
+                        "name": "author_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum units sold",
+                        "name": "min_units_sold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum units sold",
+                        "name": "max_units_sold",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -89,48 +282,1489 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Book": {
-            "type": "object",
-            "properties": {
-                "author": {
-                    "type": "string",
-                    "example": "Alan Donovan"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "name": {
-                    "type": "string",
-                    "example": "The Go Programming Language"
-                },
-                "price": {
-                    "type": "integer",
-                    "example": 45
-                },
-                "units_sold": {
-                    "type": "integer",
-                    "example": 5000
-                }
-            }
         },
-        "providers.BooksMetrics": {
-            "type": "object",
-            "properties": {
-                "books_written_by_author": {
-                    "type": "integer",
-                    "example": 2
-                },
-                "cheapest_book": {
-                    "type": "string",
-                    "example": "The Go Programming Language"
-                },
-                "mean_units_sold": {
-                    "type": "integer",
-                    "example": 10000
+        "/books/metrics/authors": {
+            "get": {
+                "description": "Get statistical metrics for each requested author, computed from a single catalog fetch. Authors can be given as repeated query parameters or as a JSON body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get books metrics for several authors",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Author names",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Author names",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetMetricsBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/providers.AuthorMetrics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Get statistical metrics for each requested author, computed from a single catalog fetch. Authors can be given as repeated query parameters or as a JSON body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get books metrics for several authors",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Author names",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Author names",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetMetricsBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/providers.AuthorMetrics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/catalog": {
+            "get": {
+                "description": "List the metrics that can be selected with the fields parameter of /books/metrics",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "List available metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/providers.Metric"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/concentration": {
+            "get": {
+                "description": "Get how concentrated sales are: Gini coefficient of units sold, Herfindahl-Hirschman index by author, share of the top 10% of titles and Pareto points",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get market concentration metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.ConcentrationMetrics"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/forecast": {
+            "get": {
+                "description": "Project the cumulative units sold of every book of an author from the recorded history, using linear regression or Holt's exponential smoothing, with a 95% confidence interval",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Forecast the units sold of an author",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Author name",
+                        "name": "author",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time after the last observation, such as 30d or 72h (default 30d, max 365d)",
+                        "name": "horizon",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "linear",
+                            "holt"
+                        ],
+                        "type": "string",
+                        "description": "Forecasting method",
+                        "name": "method",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/histogram": {
+            "get": {
+                "description": "Get the distribution of price or units sold over buckets of fixed width, explicit edges or quantiles, with the IDs of the books in each bucket. Accepts the same filters as /books.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get a histogram of price or units sold",
+                "parameters": [
+                    {
+                        "enum": [
+                            "price",
+                            "units_sold"
+                        ],
+                        "type": "string",
+                        "description": "Field to bucket",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Fixed bucket width",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated, strictly increasing bucket edges",
+                        "name": "edges",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of quantile buckets",
+                        "name": "quantiles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the book name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the author name",
+                        "name": "author_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum units sold",
+                        "name": "min_units_sold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum units sold",
+                        "name": "max_units_sold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.Histogram"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/revenue": {
+            "get": {
+                "description": "Get revenue metrics computed from price × units sold: total revenue, revenue per author, top revenue titles and each book's share of the total",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get revenue metrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of top revenue titles to return (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.RevenueMetrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/metrics/timeseries": {
+            "get": {
+                "description": "Get mean units sold, total revenue and book count of the recorded catalog versions sampled at regular intervals, with the change since the previous point",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get metrics over time",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start time in RFC 3339 format (default 7 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time in RFC 3339 format (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sampling interval such as 6h or 1d (default 1d)",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.TimeSeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/trending": {
+            "get": {
+                "description": "Rank books by units sold per day between the catalog versions in effect at from and to, and report how their bestseller rank moved (up, down, same or new)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get trending books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start time in RFC 3339 format (default 7 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time in RFC 3339 format (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books to return (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.Trending"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/forecast": {
+            "get": {
+                "description": "Project the cumulative units sold of a book from its recorded history, using linear regression or Holt's exponential smoothing, with a 95% confidence interval",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Forecast the units sold of a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time after the last observation, such as 30d or 72h (default 30d, max 365d)",
+                        "name": "horizon",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "linear",
+                            "holt"
+                        ],
+                        "type": "string",
+                        "description": "Forecasting method",
+                        "name": "method",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/history": {
+            "get": {
+                "description": "Get the changes recorded for a book across the fetched catalog versions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get the history of a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/providers.BookHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.GetMetricsBatchRequest": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Book": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "Alan Donovan"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "The Go Programming Language"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 45
+                },
+                "units_sold": {
+                    "type": "integer",
+                    "example": 5000
+                }
+            }
+        },
+        "models.BookChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/models.Book"
+                },
+                "before": {
+                    "$ref": "#/definitions/models.Book"
+                },
+                "changed_at": {
+                    "type": "string",
+                    "example": "2025-01-10T12:00:00Z"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "updated"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "providers.Anomaly": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "current": {
+                    "$ref": "#/definitions/models.Book"
+                },
+                "detected_at": {
+                    "type": "string",
+                    "example": "2025-01-10T12:00:00Z"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "price changed from 40 to 0"
+                },
+                "previous": {
+                    "$ref": "#/definitions/models.Book"
+                },
+                "quarantined": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "price_change"
+                },
+                "z_score": {
+                    "type": "number",
+                    "example": 4.2
+                }
+            }
+        },
+        "providers.AuthorMetrics": {
+            "type": "object",
+            "properties": {
+                "books_written_by_author": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "cheapest_book": {
+                    "type": "string",
+                    "example": "The Go Programming Language"
+                },
+                "found": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mean_units_sold": {
+                    "type": "integer",
+                    "example": 10000
+                }
+            }
+        },
+        "providers.BookHistory": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookChange"
+                    }
+                },
+                "current": {
+                    "$ref": "#/definitions/models.Book"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "providers.BookRevenue": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "Alan Donovan"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "The Go Programming Language"
+                },
+                "revenue": {
+                    "type": "integer",
+                    "example": 225000
+                },
+                "share": {
+                    "type": "number",
+                    "example": 0.25
+                }
+            }
+        },
+        "providers.BooksFilter": {
+            "type": "object",
+            "properties": {
+                "author_contains": {
+                    "type": "string",
+                    "example": "donovan"
+                },
+                "max_price": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "max_units_sold": {
+                    "type": "integer",
+                    "example": 50000
+                },
+                "min_price": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "min_units_sold": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "name": {
+                    "type": "string",
+                    "example": "go"
+                }
+            }
+        },
+        "providers.BooksMetrics": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/providers.BooksFilter"
+                },
+                "matched_books": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "providers.ConcentrationMetrics": {
+            "type": "object",
+            "properties": {
+                "gini": {
+                    "description": "Gini is the Gini coefficient of units sold per title, from 0 (equal) to 1",
+                    "type": "number",
+                    "example": 0.25
+                },
+                "hhi": {
+                    "description": "HHI is the Herfindahl-Hirschman index of units sold by author, from 0 to 10000",
+                    "type": "number",
+                    "example": 3800
+                },
+                "pareto_points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.ParetoPoint"
+                    }
+                },
+                "top_decile_share": {
+                    "description": "TopDecileShare is the share of units sold by the best selling 10% of titles",
+                    "type": "number",
+                    "example": 0.4
+                }
+            }
+        },
+        "providers.DataQualityReport": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string",
+                    "example": "2025-01-10T12:00:00Z"
+                },
+                "decode": {
+                    "$ref": "#/definitions/repositories.DecodeReport"
+                },
+                "rejected_books": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.94
+                },
+                "total_books": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "valid_books": {
+                    "type": "integer",
+                    "example": 47
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.RuleViolation"
+                    }
+                }
+            }
+        },
+        "providers.Forecast": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2025-02-09T12:00:00Z"
+                },
+                "author": {
+                    "type": "string",
+                    "example": "Alan Donovan"
+                },
+                "book_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "confidence": {
+                    "type": "number",
+                    "example": 0.95
+                },
+                "horizon": {
+                    "type": "string",
+                    "example": "720h0m0s"
+                },
+                "last_observed": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "lower": {
+                    "type": "number",
+                    "example": 5800
+                },
+                "method": {
+                    "type": "string",
+                    "example": "linear"
+                },
+                "observations": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "overflowed_versions": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        7
+                    ]
+                },
+                "units_sold": {
+                    "type": "number",
+                    "example": 6200
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 6600
+                }
+            }
+        },
+        "providers.Histogram": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.HistogramBucket"
+                    }
+                },
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                }
+            }
+        },
+        "providers.HistogramBucket": {
+            "type": "object",
+            "properties": {
+                "book_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "lower": {
+                    "type": "number",
+                    "example": 20
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 30
+                }
+            }
+        },
+        "providers.Metric": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Mean units sold per book"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "mean_units_sold"
+                }
+            }
+        },
+        "providers.ParetoPoint": {
+            "type": "object",
+            "properties": {
+                "sales_share": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "titles_share": {
+                    "type": "number",
+                    "example": 0.2
+                }
+            }
+        },
+        "providers.RevenueMetrics": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.BookRevenue"
+                    }
+                },
+                "overflowed_books": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        7
+                    ]
+                },
+                "revenue_by_author": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "top_titles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.BookRevenue"
+                    }
+                },
+                "total_revenue": {
+                    "type": "integer",
+                    "example": 900000
+                }
+            }
+        },
+        "providers.RuleViolation": {
+            "type": "object",
+            "properties": {
+                "book_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "critical": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "description": {
+                    "type": "string",
+                    "example": "The book has a price of zero"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "zero_price"
+                }
+            }
+        },
+        "providers.TimeSeries": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-03T00:00:00Z"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "24h0m0s"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.TimeSeriesPoint"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-01-10T00:00:00Z"
+                }
+            }
+        },
+        "providers.TimeSeriesDelta": {
+            "type": "object",
+            "properties": {
+                "book_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "book_count_pct": {
+                    "type": "number",
+                    "example": 10
+                },
+                "mean_units_sold": {
+                    "type": "number",
+                    "example": 250.5
+                },
+                "mean_units_sold_pct": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "total_revenue": {
+                    "type": "number",
+                    "example": 12000
+                },
+                "total_revenue_pct": {
+                    "type": "number",
+                    "example": 1.2
+                }
+            }
+        },
+        "providers.TimeSeriesPoint": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2025-01-10T00:00:00Z"
+                },
+                "book_count": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "delta": {
+                    "$ref": "#/definitions/providers.TimeSeriesDelta"
+                },
+                "mean_units_sold": {
+                    "type": "number",
+                    "example": 10000.5
+                },
+                "overflowed_books": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        7
+                    ]
+                },
+                "total_revenue": {
+                    "type": "integer",
+                    "example": 900000
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "providers.Trending": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/providers.TrendingBook"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-03T00:00:00Z"
+                },
+                "from_version": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-01-10T00:00:00Z"
+                },
+                "to_version": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "providers.TrendingBook": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "Alan Donovan"
+                },
+                "bestseller_rank": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "movement": {
+                    "type": "string",
+                    "example": "up"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "The Go Programming Language"
+                },
+                "previous_bestseller_rank": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "rank": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "units_per_day": {
+                    "type": "number",
+                    "example": 100
+                },
+                "units_sold": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "units_sold_in_period": {
+                    "type": "integer",
+                    "example": 700
+                }
+            }
+        },
+        "repositories.DecodeReport": {
+            "type": "object",
+            "properties": {
+                "coerced": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "decoded": {
+                    "type": "integer",
+                    "example": 49
+                },
+                "dropped_issues": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "dropped_quarantined": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "issue_counts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.IssueCount"
+                    }
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repositories.RecordIssue"
+                    }
+                },
+                "quarantined": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "rejected": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "repositories.IssueCount": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "coerced"
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                }
+            }
+        },
+        "repositories.MirrorStats": {
+            "type": "object",
+            "properties": {
+                "cancelled": {
+                    "type": "integer",
+                    "example": 21
+                },
+                "failures": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "latency_max_ns": {
+                    "type": "integer",
+                    "example": 900000000
+                },
+                "latency_p50_ns": {
+                    "type": "integer",
+                    "example": 85000000
+                },
+                "latency_p99_ns": {
+                    "type": "integer",
+                    "example": 410000000
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://mirror-1.example.com/books"
+                },
+                "wins": {
+                    "type": "integer",
+                    "example": 97
+                }
+            }
+        },
+        "repositories.RateLimitStats": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "burst": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 5
+                },
+                "rejected": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "source": {
+                    "type": "string",
+                    "example": "6781a8a5f9b1c2d3e4f5a6b7.mockapi.io"
+                },
+                "throttled": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "waited_ns": {
+                    "type": "integer",
+                    "example": 1500000000
+                }
+            }
+        },
+        "repositories.RecordIssue": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "coerced"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "message": {
+                    "type": "string",
+                    "example": "string converted to number"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "\"45\""
                 }
             }
         }
@@ -1,7 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"educabot.com/bookshop/providers"
 	"github.com/gin-gonic/gin"
@@ -11,8 +17,74 @@ type BooksHandler struct {
 	booksProvider providers.BooksProvider
 }
 
+// BooksFilterRequest represents the filter query parameters shared by the books endpoints
+type BooksFilterRequest struct {
+	Name           string `form:"name"`
+	AuthorContains string `form:"author_contains"`
+	MinPrice       *uint  `form:"min_price"`
+	MaxPrice       *uint  `form:"max_price"`
+	MinUnitsSold   *uint  `form:"min_units_sold"`
+	MaxUnitsSold   *uint  `form:"max_units_sold"`
+}
+
+func (r BooksFilterRequest) toFilter() providers.BooksFilter {
+	return providers.BooksFilter{
+		Name:           r.Name,
+		AuthorContains: r.AuthorContains,
+		MinPrice:       r.MinPrice,
+		MaxPrice:       r.MaxPrice,
+		MinUnitsSold:   r.MinUnitsSold,
+		MaxUnitsSold:   r.MaxUnitsSold,
+	}
+}
+
+type GetHistogramRequest struct {
+	BooksFilterRequest
+	Field     string   `form:"field" binding:"required,oneof=price units_sold"`
+	Width     uint     `form:"width"`
+	Edges     []string `form:"edges"`
+	Quantiles int      `form:"quantiles" binding:"min=0"`
+}
+
+type BookURI struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+type GetTimeSeriesRequest struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval string    `form:"interval"`
+}
+
+type GetTrendingRequest struct {
+	From  time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To    time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type GetForecastRequest struct {
+	Horizon string `form:"horizon"`
+	Method  string `form:"method" binding:"omitempty,oneof=linear holt"`
+}
+
+type GetAuthorForecastRequest struct {
+	GetForecastRequest
+	Author string `form:"author" binding:"required"`
+}
+
 type GetMetricsRequest struct {
-	Author string `form:"author"`
+	BooksFilterRequest
+	Author string   `form:"author"`
+	Stats  []string `form:"stats"`
+	Fields []string `form:"fields"`
+}
+
+type GetRevenueMetricsRequest struct {
+	Top int `form:"top" binding:"omitempty,min=1,max=100"`
+}
+
+type GetMetricsBatchRequest struct {
+	Authors []string `form:"author" json:"authors"`
 }
 
 func NewBooksHandler(booksProvider providers.BooksProvider) *BooksHandler {
@@ -21,24 +93,175 @@ func NewBooksHandler(booksProvider providers.BooksProvider) *BooksHandler {
 
 // GetBooks godoc
 // @Summary Get all books
-// @Description Get a list of all available books
+// @Description Get a list of all available books, optionally filtered
 // @Tags books
 // @Accept json
 // @Produce json
+// @Param name query string false "Case-insensitive substring of the book name"
+// @Param author_contains query string false "Case-insensitive substring of the author name"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param min_units_sold query int false "Minimum units sold"
+// @Param max_units_sold query int false "Maximum units sold"
 // @Success 200 {array} models.Book
+// @Failure 400 {object} map[string]string
 // @Router /books [get]
 func (h *BooksHandler) GetBooks(ctx *gin.Context) {
-	books := h.booksProvider.GetBooks(ctx.Request.Context())
+	var query BooksFilterRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	filter := query.toFilter()
+	if err := filter.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: minimum is greater than maximum"})
+		return
+	}
+
+	books := h.booksProvider.GetBooks(ctx.Request.Context(), filter)
 	ctx.JSON(http.StatusOK, books)
 }
 
+// GetBookHistory godoc
+// @Summary Get the history of a book
+// @Description Get the changes recorded for a book across the fetched catalog versions
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} providers.BookHistory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /books/{id}/history [get]
+func (h *BooksHandler) GetBookHistory(ctx *gin.Context) {
+	var uri BookURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	history, err := h.booksProvider.GetBookHistory(uri.ID)
+	if errors.Is(err, providers.ErrBookNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get book history"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// GetBookForecast godoc
+// @Summary Forecast the units sold of a book
+// @Description Project the cumulative units sold of a book from its recorded history, using linear regression or Holt's exponential smoothing, with a 95% confidence interval
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param horizon query string false "Time after the last observation, such as 30d or 72h (default 30d, max 365d)"
+// @Param method query string false "Forecasting method" Enums(linear, holt)
+// @Success 200 {object} providers.Forecast
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /books/{id}/forecast [get]
+func (h *BooksHandler) GetBookForecast(ctx *gin.Context) {
+	var uri BookURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+	var query GetForecastRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	opts, err := query.toOptions()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid horizon"})
+		return
+	}
+
+	forecast, err := h.booksProvider.GetBookForecast(uri.ID, opts)
+	writeForecast(ctx, forecast, err)
+}
+
+// GetAuthorForecast godoc
+// @Summary Forecast the units sold of an author
+// @Description Project the cumulative units sold of every book of an author from the recorded history, using linear regression or Holt's exponential smoothing, with a 95% confidence interval
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param author query string true "Author name"
+// @Param horizon query string false "Time after the last observation, such as 30d or 72h (default 30d, max 365d)"
+// @Param method query string false "Forecasting method" Enums(linear, holt)
+// @Success 200 {object} providers.Forecast
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /books/metrics/forecast [get]
+func (h *BooksHandler) GetAuthorForecast(ctx *gin.Context) {
+	var query GetAuthorForecastRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	opts, err := query.toOptions()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid horizon"})
+		return
+	}
+
+	forecast, err := h.booksProvider.GetAuthorForecast(query.Author, opts)
+	writeForecast(ctx, forecast, err)
+}
+
+func (r GetForecastRequest) toOptions() (providers.ForecastOptions, error) {
+	opts := providers.ForecastOptions{Method: r.Method}
+	if r.Horizon == "" {
+		return opts, nil
+	}
+	horizon, err := parseDuration(r.Horizon)
+	if err != nil || horizon <= 0 {
+		return opts, errors.New("invalid horizon")
+	}
+	opts.Horizon = horizon
+	return opts, nil
+}
+
+func writeForecast(ctx *gin.Context, forecast *providers.Forecast, err error) {
+	switch {
+	case errors.Is(err, providers.ErrInvalidForecast):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, providers.ErrBookNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "No recorded history"})
+	case errors.Is(err, providers.ErrNotEnoughHistory):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Not enough catalog history to forecast"})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get forecast"})
+	default:
+		ctx.JSON(http.StatusOK, forecast)
+	}
+}
+
 // GetMetrics godoc
 // @Summary Get books metrics
-// @Description Get statistical metrics about books, optionally filtered by author
+// @Description Get statistical metrics about books, optionally computed over the books matching the same filters as /books. Every computed metric is written under its name, see /books/metrics/catalog
 // @Tags books
 // @Accept json
 // @Produce json
 // @Param author query string false "Author name to filter metrics"
+// @Param stats query string false "Comma separated distribution statistics for units sold and price (min, max, mean, median, p90, p95, p99, stddev, iqr)"
+// @Param fields query string false "Comma separated metrics to compute, see /books/metrics/catalog"
+// @Param name query string false "Case-insensitive substring of the book name"
+// @Param author_contains query string false "Case-insensitive substring of the author name"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param min_units_sold query int false "Minimum units sold"
+// @Param max_units_sold query int false "Maximum units sold"
 // @Success 200 {object} providers.BooksMetrics
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -50,11 +273,387 @@ func (h *BooksHandler) GetMetrics(ctx *gin.Context) {
 		return
 	}
 
-	metrics, err := h.booksProvider.GetMetrics(ctx.Request.Context(), query.Author)
+	metrics, err := h.booksProvider.GetMetrics(ctx.Request.Context(), providers.MetricsOptions{
+		Author: query.Author,
+		Stats:  splitList(query.Stats),
+		Fields: splitList(query.Fields),
+		Filter: query.toFilter(),
+	})
+	if errors.Is(err, providers.ErrInvalidFilter) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: minimum is greater than maximum"})
+		return
+	}
+	if errors.Is(err, providers.ErrUnknownStat) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown statistic, available: " + strings.Join(providers.AvailableStats, ", ")})
+		return
+	}
+	if errors.Is(err, providers.ErrUnknownMetric) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get metrics"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metrics)
+}
+
+// GetMetricsCatalog godoc
+// @Summary List available metrics
+// @Description List the metrics that can be selected with the fields parameter of /books/metrics
+// @Tags books
+// @Produce json
+// @Success 200 {array} providers.Metric
+// @Router /books/metrics/catalog [get]
+func (h *BooksHandler) GetMetricsCatalog(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.booksProvider.GetMetricsCatalog())
+}
+
+// GetMetricsBatch godoc
+// @Summary Get books metrics for several authors
+// @Description Get statistical metrics for each requested author, computed from a single catalog fetch. Authors can be given as repeated query parameters or as a JSON body.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param author query []string false "Author names" collectionFormat(multi)
+// @Param request body GetMetricsBatchRequest false "Author names"
+// @Success 200 {object} map[string]providers.AuthorMetrics
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /books/metrics/authors [get]
+// @Router /books/metrics/authors [post]
+func (h *BooksHandler) GetMetricsBatch(ctx *gin.Context) {
+	var request GetMetricsBatchRequest
+	var err error
+	if ctx.Request.Method == http.MethodPost {
+		err = ctx.ShouldBindJSON(&request)
+	} else {
+		err = ctx.ShouldBindQuery(&request)
+	}
 	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	metrics, err := h.booksProvider.GetMetricsBatch(ctx.Request.Context(), request.Authors)
+	switch {
+	case errors.Is(err, providers.ErrNoAuthors):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "At least one author is required"})
+		return
+	case errors.Is(err, providers.ErrTooManyAuthors):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Too many authors requested"})
+		return
+	case errors.Is(err, providers.ErrCatalogUnavailable):
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Books catalog unavailable"})
+		return
+	case err != nil:
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get metrics"})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, metrics)
-}
\ No newline at end of file
+}
+
+// GetRevenueMetrics godoc
+// @Summary Get revenue metrics
+// @Description Get revenue metrics computed from price × units sold: total revenue, revenue per author, top revenue titles and each book's share of the total
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param top query int false "Number of top revenue titles to return (default 10, max 100)"
+// @Success 200 {object} providers.RevenueMetrics
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /books/metrics/revenue [get]
+func (h *BooksHandler) GetRevenueMetrics(ctx *gin.Context) {
+	var query GetRevenueMetricsRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	metrics, err := h.booksProvider.GetRevenueMetrics(ctx.Request.Context(), query.Top)
+	if errors.Is(err, providers.ErrCatalogUnavailable) {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Books catalog unavailable"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get revenue metrics"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metrics)
+}
+
+// GetHistogram godoc
+// @Summary Get a histogram of price or units sold
+// @Description Get the distribution of price or units sold over buckets of fixed width, explicit edges or quantiles, with the IDs of the books in each bucket. Accepts the same filters as /books.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param field query string true "Field to bucket" Enums(price, units_sold)
+// @Param width query int false "Fixed bucket width"
+// @Param edges query string false "Comma separated, strictly increasing bucket edges"
+// @Param quantiles query int false "Number of quantile buckets"
+// @Param name query string false "Case-insensitive substring of the book name"
+// @Param author_contains query string false "Case-insensitive substring of the author name"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param min_units_sold query int false "Minimum units sold"
+// @Param max_units_sold query int false "Maximum units sold"
+// @Success 200 {object} providers.Histogram
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /books/metrics/histogram [get]
+func (h *BooksHandler) GetHistogram(ctx *gin.Context) {
+	var query GetHistogramRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	edges, err := parseFloats(splitList(query.Edges))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid edges"})
+		return
+	}
+
+	histogram, err := h.booksProvider.GetHistogram(ctx.Request.Context(), providers.HistogramOptions{
+		Field:     query.Field,
+		Width:     query.Width,
+		Edges:     edges,
+		Quantiles: query.Quantiles,
+		Filter:    query.toFilter(),
+	})
+	switch {
+	case errors.Is(err, providers.ErrInvalidFilter):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: minimum is greater than maximum"})
+		return
+	case errors.Is(err, providers.ErrInvalidHistogram):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, providers.ErrCatalogUnavailable):
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Books catalog unavailable"})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get histogram"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, histogram)
+}
+
+// GetConcentrationMetrics godoc
+// @Summary Get market concentration metrics
+// @Description Get how concentrated sales are: Gini coefficient of units sold, Herfindahl-Hirschman index by author, share of the top 10% of titles and Pareto points
+// @Tags books
+// @Accept json
+// @Produce json
+// @Success 200 {object} providers.ConcentrationMetrics
+// @Failure 500 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /books/metrics/concentration [get]
+func (h *BooksHandler) GetConcentrationMetrics(ctx *gin.Context) {
+	metrics, err := h.booksProvider.GetConcentrationMetrics(ctx.Request.Context())
+	if errors.Is(err, providers.ErrCatalogUnavailable) {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Books catalog unavailable"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get concentration metrics"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metrics)
+}
+
+// GetTimeSeries godoc
+// @Summary Get metrics over time
+// @Description Get mean units sold, total revenue and book count of the recorded catalog versions sampled at regular intervals, with the change since the previous point
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param from query string false "Start time in RFC 3339 format (default 7 days before to)"
+// @Param to query string false "End time in RFC 3339 format (default now)"
+// @Param interval query string false "Sampling interval such as 6h or 1d (default 1d)"
+// @Success 200 {object} providers.TimeSeries
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /books/metrics/timeseries [get]
+func (h *BooksHandler) GetTimeSeries(ctx *gin.Context) {
+	var query GetTimeSeriesRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	var interval time.Duration
+	if query.Interval != "" {
+		var err error
+		if interval, err = parseDuration(query.Interval); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval"})
+			return
+		}
+	}
+
+	series, err := h.booksProvider.GetTimeSeries(providers.TimeSeriesOptions{
+		From:     query.From,
+		To:       query.To,
+		Interval: interval,
+	})
+	if errors.Is(err, providers.ErrInvalidTimeSeries) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get time series"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, series)
+}
+
+// GetTrending godoc
+// @Summary Get trending books
+// @Description Rank books by units sold per day between the catalog versions in effect at from and to, and report how their bestseller rank moved (up, down, same or new)
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param from query string false "Start time in RFC 3339 format (default 7 days before to)"
+// @Param to query string false "End time in RFC 3339 format (default now)"
+// @Param limit query int false "Number of books to return (default 10, max 100)"
+// @Success 200 {object} providers.Trending
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /books/trending [get]
+func (h *BooksHandler) GetTrending(ctx *gin.Context) {
+	var query GetTrendingRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	trending, err := h.booksProvider.GetTrending(providers.TrendingOptions{
+		From:  query.From,
+		To:    query.To,
+		Limit: query.Limit,
+	})
+	switch {
+	case errors.Is(err, providers.ErrInvalidTrending):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, providers.ErrNotEnoughHistory):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Not enough catalog history for the requested period"})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trending books"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, trending)
+}
+
+// GetAnomalies godoc
+// @Summary Get catalog anomalies
+// @Description Get the suspicious changes found when comparing each fetched catalog with the previous one: large price changes, decreasing units sold, vanished and duplicate IDs
+// @Tags admin
+// @Produce json
+// @Success 200 {array} providers.Anomaly
+// @Router /admin/anomalies [get]
+func (h *BooksHandler) GetAnomalies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.booksProvider.GetAnomalies())
+}
+
+// GetRateLimits godoc
+// @Summary Get upstream rate limit stats
+// @Description Get, per upstream source, the configured token bucket and how many outgoing calls were allowed, throttled while waiting for a token or rejected
+// @Tags admin
+// @Produce json
+// @Success 200 {array} repositories.RateLimitStats
+// @Router /admin/rate-limits [get]
+func (h *BooksHandler) GetRateLimits(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.booksProvider.GetRateLimits())
+}
+
+// GetMirrors godoc
+// @Summary Get upstream mirror stats
+// @Description Get, for BOOKS_API_URL and each of BOOKS_API_MIRRORS, how many hedged requests were sent, won, failed or cancelled, along with the latency of the successful ones
+// @Tags admin
+// @Produce json
+// @Success 200 {array} repositories.MirrorStats
+// @Router /admin/mirrors [get]
+func (h *BooksHandler) GetMirrors(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.booksProvider.GetMirrors())
+}
+
+// GetDataQuality godoc
+// @Summary Get the catalog data quality report
+// @Description Validate the upstream catalog against the configured rules (empty names, missing authors, zero prices, duplicate IDs) and get the violations grouped by rule, with the offending IDs and the share of valid books as score, along with the records the upstream decoding had to coerce or drop
+// @Tags admin
+// @Produce json
+// @Success 200 {object} providers.DataQualityReport
+// @Failure 503 {object} map[string]string
+// @Router /admin/data-quality [get]
+func (h *BooksHandler) GetDataQuality(ctx *gin.Context) {
+	report, err := h.booksProvider.GetDataQuality(ctx.Request.Context())
+	if errors.Is(err, providers.ErrNoQualityReport) {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "No catalog has been fetched yet"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get data quality report"})
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// maxDays is the largest number of days a time.Duration can hold
+const maxDays = math.MaxInt64 / int64(24*time.Hour)
+
+// parseDuration accepts Go durations and a whole number of days such as 30d
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > maxDays || n < -maxDays {
+			return 0, fmt.Errorf("%q is out of range", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func parseFloats(values []string) ([]float64, error) {
+	floats := make([]float64, len(values))
+	for i, value := range values {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%q is not a finite number", value)
+		}
+		floats[i] = f
+	}
+	return floats, nil
+}
+
+// splitList flattens repeated and comma separated query values, dropping empty items
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -4,37 +4,212 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"educabot.com/bookshop/models"
 	"educabot.com/bookshop/providers"
+	"educabot.com/bookshop/repositories"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 // Mock implementation of BooksProvider
 type mockBooksProvider struct {
-	books       []models.Book
-	shouldError bool
+	books        []models.Book
+	shouldError  bool
+	batchError   error
+	catalogError error
 }
 
-func (m *mockBooksProvider) GetBooks(ctx context.Context) []models.Book {
-	return m.books
+func (m *mockBooksProvider) GetBooks(ctx context.Context, filter providers.BooksFilter) []models.Book {
+	return filter.Apply(m.books)
 }
 
-func (m *mockBooksProvider) GetMetrics(ctx context.Context, author string) (*providers.BooksMetrics, error) {
+func (m *mockBooksProvider) GetMetrics(ctx context.Context, opts providers.MetricsOptions) (*providers.BooksMetrics, error) {
 	if m.shouldError {
 		return nil, errors.New("provider error")
 	}
-	return &providers.BooksMetrics{
-		MeanUnitsSold:        10000,
-		CheapestBook:         "The Go Programming Language",
-		BooksWrittenByAuthor: 1,
+	for _, stat := range opts.Stats {
+		if stat == "unknown" {
+			return nil, providers.ErrUnknownStat
+		}
+	}
+	for _, field := range opts.Fields {
+		if field == "unknown" {
+			return nil, fmt.Errorf("%w %q", providers.ErrUnknownMetric, field)
+		}
+	}
+	if err := opts.Filter.Validate(); err != nil {
+		return nil, err
+	}
+	metrics := &providers.BooksMetrics{
+		Filter:       opts.Filter,
+		MatchedBooks: len(opts.Filter.Apply(m.books)),
+	}
+	metrics.Set("mean_units_sold", uint(10000))
+	metrics.Set("cheapest_book", "The Go Programming Language")
+	metrics.Set("books_written_by_author", uint(1))
+	if len(opts.Stats) > 0 {
+		stats := providers.Distribution{}
+		for _, stat := range opts.Stats {
+			stats[stat] = 1
+		}
+		metrics.Set("units_sold_stats", stats)
+	}
+	return metrics, nil
+}
+
+func (m *mockBooksProvider) GetMetricsBatch(ctx context.Context, authors []string) (map[string]providers.AuthorMetrics, error) {
+	if m.batchError != nil {
+		return nil, m.batchError
+	}
+	metrics := make(map[string]providers.AuthorMetrics, len(authors))
+	for _, author := range authors {
+		metrics[author] = providers.AuthorMetrics{Found: author == "Alan Donovan", BooksWrittenByAuthor: 1}
+	}
+	return metrics, nil
+}
+
+func (m *mockBooksProvider) GetRevenueMetrics(ctx context.Context, top int) (*providers.RevenueMetrics, error) {
+	if m.catalogError != nil {
+		return nil, m.catalogError
+	}
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	return &providers.RevenueMetrics{
+		TotalRevenue:    200000,
+		RevenueByAuthor: map[string]uint64{"Alan Donovan": 200000},
+		TopTitles:       []providers.BookRevenue{{ID: 1, Name: "The Go Programming Language", Revenue: 200000, Share: 1}},
+	}, nil
+}
+
+func (m *mockBooksProvider) GetHistogram(ctx context.Context, opts providers.HistogramOptions) (*providers.Histogram, error) {
+	if m.catalogError != nil {
+		return nil, m.catalogError
+	}
+	if opts.Width == 0 && len(opts.Edges) == 0 && opts.Quantiles == 0 {
+		return nil, providers.ErrInvalidHistogram
+	}
+	books := opts.Filter.Apply(m.books)
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return &providers.Histogram{
+		Field:   opts.Field,
+		Buckets: []providers.HistogramBucket{{Lower: 0, Upper: 100, Count: len(books), BookIDs: ids}},
+	}, nil
+}
+
+func (m *mockBooksProvider) GetConcentrationMetrics(ctx context.Context) (*providers.ConcentrationMetrics, error) {
+	if m.catalogError != nil {
+		return nil, m.catalogError
+	}
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	return &providers.ConcentrationMetrics{Gini: 0.25, HHI: 3800, TopDecileShare: 0.4}, nil
+}
+
+func (m *mockBooksProvider) GetMetricsCatalog() []providers.Metric {
+	return []providers.Metric{{Name: "mean_units_sold", Description: "Mean units sold per book", Default: true}}
+}
+
+func (m *mockBooksProvider) GetBookHistory(id uint) (*providers.BookHistory, error) {
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	for _, book := range m.books {
+		if book.ID == id {
+			return &providers.BookHistory{
+				ID:      id,
+				Current: &book,
+				Changes: []models.BookChange{{Version: 1, Type: models.BookAdded, After: &book}},
+			}, nil
+		}
+	}
+	return nil, providers.ErrBookNotFound
+}
+
+func (m *mockBooksProvider) GetTimeSeries(opts providers.TimeSeriesOptions) (*providers.TimeSeries, error) {
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	if opts.Interval < 0 {
+		return nil, providers.ErrInvalidTimeSeries
+	}
+	return &providers.TimeSeries{
+		From:     opts.From,
+		To:       opts.To,
+		Interval: opts.Interval.String(),
+		Points:   []providers.TimeSeriesPoint{{At: opts.From, Version: 1, MeanUnitsSold: 100, BookCount: 1}},
+	}, nil
+}
+
+func (m *mockBooksProvider) GetTrending(opts providers.TrendingOptions) (*providers.Trending, error) {
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	if len(m.books) == 0 {
+		return nil, providers.ErrNotEnoughHistory
+	}
+	trending := &providers.Trending{From: opts.From, To: opts.To, FromVersion: 1, ToVersion: 2}
+	for i, book := range m.books {
+		trending.Books = append(trending.Books, providers.TrendingBook{ID: book.ID, Name: book.Name, Rank: i + 1, Movement: providers.MovementSame})
+	}
+	return trending, nil
+}
+
+func (m *mockBooksProvider) GetAnomalies() []providers.Anomaly {
+	return []providers.Anomaly{{Type: providers.AnomalyPriceChange, BookID: 1, Message: "price changed from 40 to 0"}}
+}
+
+func (m *mockBooksProvider) GetRateLimits() []repositories.RateLimitStats {
+	return []repositories.RateLimitStats{{Source: "books.example.com", Rate: 5, Burst: 10, Allowed: 12, Throttled: 3}}
+}
+
+func (m *mockBooksProvider) GetMirrors() []repositories.MirrorStats {
+	return []repositories.MirrorStats{{URL: "https://mirror.example.com/books", Requests: 10, Wins: 7, Cancelled: 3}}
+}
+
+func (m *mockBooksProvider) GetDataQuality(ctx context.Context) (*providers.DataQualityReport, error) {
+	if m.shouldError {
+		return nil, providers.ErrNoQualityReport
+	}
+	return &providers.DataQualityReport{
+		TotalBooks: 4,
+		ValidBooks: 3,
+		Score:      0.75,
+		Violations: []providers.RuleViolation{{Rule: providers.RuleZeroPrice, Count: 1, BookIDs: []uint{2}}},
 	}, nil
 }
 
+func (m *mockBooksProvider) GetBookForecast(id uint, opts providers.ForecastOptions) (*providers.Forecast, error) {
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	if id != 1 {
+		return nil, providers.ErrBookNotFound
+	}
+	return &providers.Forecast{BookID: id, Method: opts.Method, Horizon: opts.Horizon.String(), UnitsSold: 1200, Lower: 1100, Upper: 1300}, nil
+}
+
+func (m *mockBooksProvider) GetAuthorForecast(author string, opts providers.ForecastOptions) (*providers.Forecast, error) {
+	if m.shouldError {
+		return nil, errors.New("provider error")
+	}
+	if author == "Newcomer" {
+		return nil, providers.ErrNotEnoughHistory
+	}
+	return &providers.Forecast{Author: author, Method: opts.Method, Horizon: opts.Horizon.String(), UnitsSold: 1200}, nil
+}
+
 func TestGetBooks_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -85,6 +260,50 @@ func TestGetBooks_EmptyResult(t *testing.T) {
 	assert.Len(t, books, 0)
 }
 
+func TestGetBooks_Filtered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockProvider := &mockBooksProvider{
+		books: []models.Book{
+			{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+			{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 200, Price: 30},
+			{ID: 3, Name: "Book 3", Author: "Author 2", UnitsSold: 300, Price: 40},
+		},
+	}
+
+	handler := NewBooksHandler(mockProvider)
+	r := gin.Default()
+	r.GET("/books", handler.GetBooks)
+
+	req := httptest.NewRequest(http.MethodGet, "/books?author_contains=author+2&max_price=35", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var books []models.Book
+	err := json.Unmarshal(res.Body.Bytes(), &books)
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, uint(2), books[0].ID)
+}
+
+func TestGetBooks_InvalidFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books", handler.GetBooks)
+
+	for _, query := range []string{"min_price=abc", "min_price=50&max_price=10", "min_units_sold=-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/books?"+query, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, query)
+	}
+}
+
 func TestGetMetrics_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -206,3 +425,741 @@ func TestGetMetrics_NoAuthorParam(t *testing.T) {
 	// Should return metrics with empty author (empty string)
 	assert.Equal(t, 10000, int(resBody["mean_units_sold"].(float64)))
 }
+
+func TestGetMetrics_Stats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics", handler.GetMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics?stats=median,p90&stats=iqr", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody struct {
+		UnitsSoldStats providers.Distribution `json:"units_sold_stats"`
+	}
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody.UnitsSoldStats, 3)
+	assert.Contains(t, resBody.UnitsSoldStats, "median")
+	assert.Contains(t, resBody.UnitsSoldStats, "p90")
+	assert.Contains(t, resBody.UnitsSoldStats, "iqr")
+}
+
+func TestGetMetrics_UnknownStat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics", handler.GetMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics?stats=unknown", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestGetMetrics_Filtered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockProvider := &mockBooksProvider{
+		books: []models.Book{
+			{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+			{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 200, Price: 40},
+		},
+	}
+
+	handler := NewBooksHandler(mockProvider)
+	r := gin.Default()
+	r.GET("/books/metrics", handler.GetMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics?max_price=30&author_contains=author", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody map[string]interface{}
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, int(resBody["matched_books"].(float64)))
+	assert.Equal(t, map[string]interface{}{"author_contains": "author", "max_price": 30.0}, resBody["filter"])
+}
+
+func TestGetMetrics_InvalidFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics", handler.GetMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics?min_units_sold=10&max_units_sold=5", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestGetMetrics_UnknownField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics", handler.GetMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics?fields=mean_units_sold,unknown", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	var resBody map[string]interface{}
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, `unknown metric "unknown"`, resBody["error"])
+}
+
+func TestGetMetricsCatalog_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/catalog", handler.GetMetricsCatalog)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/catalog", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody []providers.Metric
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody, 1)
+	assert.Equal(t, "mean_units_sold", resBody[0].Name)
+}
+
+func TestGetMetricsBatch_QueryOK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/authors", handler.GetMetricsBatch)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/authors?author=Alan+Donovan&author=Unknown", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody map[string]providers.AuthorMetrics
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody, 2)
+	assert.True(t, resBody["Alan Donovan"].Found)
+	assert.False(t, resBody["Unknown"].Found)
+}
+
+func TestGetMetricsBatch_BodyOK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.POST("/books/metrics/authors", handler.GetMetricsBatch)
+
+	body := strings.NewReader(`{"authors": ["Alan Donovan"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/books/metrics/authors", body)
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody map[string]providers.AuthorMetrics
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody, 1)
+	assert.True(t, resBody["Alan Donovan"].Found)
+}
+
+func TestGetMetricsBatch_InvalidBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.POST("/books/metrics/authors", handler.GetMetricsBatch)
+
+	req := httptest.NewRequest(http.MethodPost, "/books/metrics/authors", strings.NewReader("not json"))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestGetMetricsBatch_TooManyAuthors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{batchError: providers.ErrTooManyAuthors})
+	r := gin.Default()
+	r.GET("/books/metrics/authors", handler.GetMetricsBatch)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/authors?author=A&author=B", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	var resBody map[string]interface{}
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "Too many authors requested", resBody["error"])
+}
+
+func TestGetMetricsBatch_CatalogUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{batchError: providers.ErrCatalogUnavailable})
+	r := gin.Default()
+	r.GET("/books/metrics/authors", handler.GetMetricsBatch)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/authors?author=A", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadGateway, res.Code)
+	assert.JSONEq(t, `{"error": "Books catalog unavailable"}`, res.Body.String())
+}
+
+func TestGetMetricsBatch_ProviderError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{batchError: errors.New("provider error")})
+	r := gin.Default()
+	r.GET("/books/metrics/authors", handler.GetMetricsBatch)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/authors?author=A", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestGetRevenueMetrics_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/revenue", handler.GetRevenueMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/revenue?top=5", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.RevenueMetrics
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200000), resBody.TotalRevenue)
+	assert.Len(t, resBody.TopTitles, 1)
+}
+
+func TestGetRevenueMetrics_InvalidTop(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/revenue", handler.GetRevenueMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/revenue?top=1000", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestGetRevenueMetrics_ProviderError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{shouldError: true})
+	r := gin.Default()
+	r.GET("/books/metrics/revenue", handler.GetRevenueMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/revenue", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestGetRevenueMetrics_CatalogUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{catalogError: providers.ErrCatalogUnavailable})
+	r := gin.Default()
+	r.GET("/books/metrics/revenue", handler.GetRevenueMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/revenue", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadGateway, res.Code)
+	assert.JSONEq(t, `{"error": "Books catalog unavailable"}`, res.Body.String())
+}
+
+func TestGetHistogram_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockProvider := &mockBooksProvider{
+		books: []models.Book{
+			{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+			{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 200, Price: 30},
+		},
+	}
+
+	handler := NewBooksHandler(mockProvider)
+	r := gin.Default()
+	r.GET("/books/metrics/histogram", handler.GetHistogram)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/histogram?field=price&edges=0,50,100&name=book+2", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.Histogram
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "price", resBody.Field)
+	assert.Equal(t, []uint{2}, resBody.Buckets[0].BookIDs)
+}
+
+func TestGetHistogram_InvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/histogram", handler.GetHistogram)
+
+	for _, query := range []string{"", "field=rating&width=10", "field=price&edges=1,x", "field=price&edges=NaN,5", "field=price&edges=0,Inf", "field=price"} {
+		req := httptest.NewRequest(http.MethodGet, "/books/metrics/histogram?"+query, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, query)
+	}
+}
+
+func TestGetHistogram_CatalogUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{catalogError: providers.ErrCatalogUnavailable})
+	r := gin.Default()
+	r.GET("/books/metrics/histogram", handler.GetHistogram)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/histogram?field=price&width=10", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadGateway, res.Code)
+	assert.JSONEq(t, `{"error": "Books catalog unavailable"}`, res.Body.String())
+}
+
+func TestGetConcentrationMetrics_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/concentration", handler.GetConcentrationMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/concentration", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.ConcentrationMetrics
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, resBody.Gini)
+	assert.Equal(t, 3800.0, resBody.HHI)
+}
+
+func TestGetConcentrationMetrics_ProviderError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{shouldError: true})
+	r := gin.Default()
+	r.GET("/books/metrics/concentration", handler.GetConcentrationMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/concentration", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestGetConcentrationMetrics_CatalogUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{catalogError: providers.ErrCatalogUnavailable})
+	r := gin.Default()
+	r.GET("/books/metrics/concentration", handler.GetConcentrationMetrics)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/concentration", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadGateway, res.Code)
+	assert.JSONEq(t, `{"error": "Books catalog unavailable"}`, res.Body.String())
+}
+
+func TestGetBookHistory_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockProvider := &mockBooksProvider{
+		books: []models.Book{{ID: 7, Name: "Book 7", Author: "Author 7", UnitsSold: 100, Price: 20}},
+	}
+
+	handler := NewBooksHandler(mockProvider)
+	r := gin.Default()
+	r.GET("/books/:id/history", handler.GetBookHistory)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/7/history", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.BookHistory
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), resBody.ID)
+	assert.Len(t, resBody.Changes, 1)
+	assert.Equal(t, models.BookAdded, resBody.Changes[0].Type)
+}
+
+func TestGetBookHistory_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/:id/history", handler.GetBookHistory)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/7/history", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestGetBookHistory_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/:id/history", handler.GetBookHistory)
+
+	for _, id := range []string{"abc", "-1", "0"} {
+		req := httptest.NewRequest(http.MethodGet, "/books/"+id+"/history", nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, id)
+	}
+}
+
+func TestGetTimeSeries_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/timeseries", handler.GetTimeSeries)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/timeseries?from=2025-01-01T00:00:00Z&to=2025-01-08T00:00:00Z&interval=1d", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.TimeSeries
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), resBody.From.UTC())
+	assert.Equal(t, "24h0m0s", resBody.Interval)
+	assert.Len(t, resBody.Points, 1)
+}
+
+func TestGetTimeSeries_InvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/timeseries", handler.GetTimeSeries)
+
+	for _, query := range []string{"from=yesterday", "interval=often", "interval=xd", "interval=-1h", "interval=999999999999d"} {
+		req := httptest.NewRequest(http.MethodGet, "/books/metrics/timeseries?"+query, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, query)
+	}
+}
+
+func TestGetTimeSeries_ProviderError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{shouldError: true})
+	r := gin.Default()
+	r.GET("/books/metrics/timeseries", handler.GetTimeSeries)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/timeseries", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestGetTrending_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockProvider := &mockBooksProvider{
+		books: []models.Book{{ID: 1, Name: "Book 1"}, {ID: 2, Name: "Book 2"}},
+	}
+
+	handler := NewBooksHandler(mockProvider)
+	r := gin.Default()
+	r.GET("/books/trending", handler.GetTrending)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/trending?from=2025-01-01T00:00:00Z&limit=5", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.Trending
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody.Books, 2)
+	assert.Equal(t, 1, resBody.Books[0].Rank)
+}
+
+func TestGetTrending_NotEnoughHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/trending", handler.GetTrending)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/trending", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+}
+
+func TestGetTrending_InvalidLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/trending", handler.GetTrending)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/trending?limit=500", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestGetAnomalies_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/admin/anomalies", handler.GetAnomalies)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/anomalies", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody []providers.Anomaly
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Len(t, resBody, 1)
+	assert.Equal(t, providers.AnomalyPriceChange, resBody[0].Type)
+}
+
+func TestGetBookForecast_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/:id/forecast", handler.GetBookForecast)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/1/forecast?horizon=30d&method=holt", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.Forecast
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), resBody.BookID)
+	assert.Equal(t, "holt", resBody.Method)
+	assert.Equal(t, "720h0m0s", resBody.Horizon)
+}
+
+func TestGetBookForecast_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/:id/forecast", handler.GetBookForecast)
+
+	tests := []struct {
+		url  string
+		code int
+	}{
+		{"/books/abc/forecast", http.StatusBadRequest},
+		{"/books/1/forecast?horizon=soon", http.StatusBadRequest},
+		{"/books/1/forecast?horizon=-5d", http.StatusBadRequest},
+		{"/books/1/forecast?horizon=999999999999d", http.StatusBadRequest},
+		{"/books/1/forecast?method=arima", http.StatusBadRequest},
+		{"/books/2/forecast", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, tt.code, res.Code, tt.url)
+	}
+}
+
+func TestGetAuthorForecast_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/forecast", handler.GetAuthorForecast)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/metrics/forecast?author=Alan+Donovan&horizon=72h", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.Forecast
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alan Donovan", resBody.Author)
+	assert.Equal(t, "72h0m0s", resBody.Horizon)
+}
+
+func TestGetAuthorForecast_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/books/metrics/forecast", handler.GetAuthorForecast)
+
+	tests := []struct {
+		url  string
+		code int
+	}{
+		{"/books/metrics/forecast", http.StatusBadRequest},
+		{"/books/metrics/forecast?author=Newcomer", http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, tt.code, res.Code, tt.url)
+	}
+}
+
+func TestGetDataQuality_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/admin/data-quality", handler.GetDataQuality)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/data-quality", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody providers.DataQualityReport
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, resBody.Score)
+	assert.Equal(t, []uint{2}, resBody.Violations[0].BookIDs)
+}
+
+func TestGetDataQuality_NoReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{shouldError: true})
+	r := gin.Default()
+	r.GET("/admin/data-quality", handler.GetDataQuality)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/data-quality", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+}
+
+func TestGetRateLimits_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/admin/rate-limits", handler.GetRateLimits)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/rate-limits", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody []repositories.RateLimitStats
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "books.example.com", resBody[0].Source)
+	assert.Equal(t, int64(3), resBody[0].Throttled)
+}
+
+func TestGetMirrors_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewBooksHandler(&mockBooksProvider{})
+	r := gin.Default()
+	r.GET("/admin/mirrors", handler.GetMirrors)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/mirrors", nil)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var resBody []repositories.MirrorStats
+	err := json.Unmarshal(res.Body.Bytes(), &resBody)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), resBody[0].Wins)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/fakeupstream"
+	"educabot.com/bookshop/pkg/metrics"
+	"educabot.com/bookshop/pkg/requestid"
+	"educabot.com/bookshop/providers"
+	"educabot.com/bookshop/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIntegrationRouter wires the real provider and repository to a fake
+// upstream, as main does with the real one
+func newIntegrationRouter(t *testing.T, config fakeupstream.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(fakeupstream.New(config))
+	t.Cleanup(upstream.Close)
+	t.Setenv("BOOKS_API_URL", upstream.URL+fakeupstream.BooksPath)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	registry := metrics.NewRegistry()
+	repo := repositories.NewInstrumentedBooksRepository(repositories.NewHTTPBooksRepository(logger), registry)
+	provider, err := providers.NewBooksProvider(logger, providers.WithBooksRepository(repo))
+	require.NoError(t, err)
+	handler := NewBooksHandler(provider)
+	r := gin.New()
+	r.Use(requestid.Middleware(), metrics.Middleware(registry))
+	r.GET("/books", handler.GetBooks)
+	r.GET("/books/metrics", handler.GetMetrics)
+	r.GET("/admin/data-quality", handler.GetDataQuality)
+	r.GET("/metrics", metrics.Handler(registry))
+	return r
+}
+
+func TestIntegration_BooksFromFakeUpstream(t *testing.T) {
+	r := newIntegrationRouter(t, fakeupstream.Config{Books: []models.Book{
+		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
+		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		{ID: 3, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45},
+	}})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/books?author_contains=martin", nil)
+	req.Header.Set(requestid.Header, "abc-123")
+	r.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "abc-123", res.Header().Get(requestid.Header))
+	var books []models.Book
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &books))
+	assert.Equal(t, []models.Book{{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50}}, books)
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books/metrics?author=Alan+Donovan", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	var metrics map[string]any
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &metrics))
+	assert.Equal(t, float64(11000), metrics["mean_units_sold"])
+	assert.Equal(t, "The Go Programming Language", metrics["cheapest_book"])
+	assert.Equal(t, float64(1), metrics["books_written_by_author"])
+}
+
+func TestIntegration_DataQualityOfFakeUpstream(t *testing.T) {
+	r := newIntegrationRouter(t, fakeupstream.Config{Books: []models.Book{
+		{ID: 1, Name: "Complete", Author: "Author", UnitsSold: 10, Price: 10},
+		{ID: 2, Name: "Free", Author: "Author", UnitsSold: 10},
+	}})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/admin/data-quality", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	var report providers.DataQualityReport
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &report))
+	assert.Equal(t, 0.5, report.Score)
+	assert.Equal(t, []uint{2}, report.Violations[0].BookIDs)
+	// the fake upstream sends IDs as strings, as mockapi.io does
+	assert.Equal(t, 2, report.Decode.Coerced)
+}
+
+func TestIntegration_UpstreamErrors(t *testing.T) {
+	r := newIntegrationRouter(t, fakeupstream.Config{Books: []models.Book{{ID: 1, Name: "Book"}}, ErrorRate: 1})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `[]`, res.Body.String())
+}
+
+func TestIntegration_Metrics(t *testing.T) {
+	r := newIntegrationRouter(t, fakeupstream.Config{Books: []models.Book{
+		{ID: 1, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		{ID: 2, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45},
+	}})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/books", nil))
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/books",status="200"} 1`)
+	assert.Contains(t, body, `books_api_request_duration_seconds_count{outcome="success"} 1`)
+	assert.Contains(t, body, "books_catalog_size 2\n")
+}
@@ -6,11 +6,14 @@
 package main
 
 import (
-	"fmt"
+	"os"
 
 	"educabot.com/bookshop/handlers"
 	"educabot.com/bookshop/pkg/bootstrap"
+	"educabot.com/bookshop/pkg/metrics"
+	"educabot.com/bookshop/pkg/requestid"
 	"educabot.com/bookshop/providers"
+	"educabot.com/bookshop/repositories"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -24,17 +27,39 @@ func main() {
 
 	router := gin.New()
 	router.SetTrustedProxies(nil)
+	registry := metrics.NewRegistry()
+	router.Use(requestid.Middleware(), metrics.Middleware(registry))
 
-	booksProvider := providers.NewBooksProvider(l)
+	booksRepository := repositories.NewInstrumentedBooksRepository(repositories.NewHTTPBooksRepository(l), registry)
+	booksProvider, err := providers.NewBooksProvider(l, providers.WithBooksRepository(booksRepository))
+	if err != nil {
+		l.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 	booksHandler := handlers.NewBooksHandler(booksProvider)
 	
 	router.GET("/books", booksHandler.GetBooks)
+	router.GET("/books/:id/history", booksHandler.GetBookHistory)
+	router.GET("/books/:id/forecast", booksHandler.GetBookForecast)
+	router.GET("/books/trending", booksHandler.GetTrending)
 	router.GET("/books/metrics", booksHandler.GetMetrics)
+	router.GET("/books/metrics/catalog", booksHandler.GetMetricsCatalog)
+	router.GET("/books/metrics/authors", booksHandler.GetMetricsBatch)
+	router.POST("/books/metrics/authors", booksHandler.GetMetricsBatch)
+	router.GET("/books/metrics/revenue", booksHandler.GetRevenueMetrics)
+	router.GET("/books/metrics/histogram", booksHandler.GetHistogram)
+	router.GET("/books/metrics/concentration", booksHandler.GetConcentrationMetrics)
+	router.GET("/books/metrics/timeseries", booksHandler.GetTimeSeries)
+	router.GET("/books/metrics/forecast", booksHandler.GetAuthorForecast)
+	router.GET("/admin/anomalies", booksHandler.GetAnomalies)
+	router.GET("/admin/data-quality", booksHandler.GetDataQuality)
+	router.GET("/admin/rate-limits", booksHandler.GetRateLimits)
+	router.GET("/admin/mirrors", booksHandler.GetMirrors)
+	router.GET("/metrics", metrics.Handler(registry))
 	
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	
-	fmt.Println("Starting server on :3000")
-	fmt.Println("Swagger documentation available at: http://localhost:3000/swagger/index.html")
+	l.Info("Starting server", "addr", ":3000", "swagger", "http://localhost:3000/swagger/index.html")
 	router.Run(":3000")
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Kinds of change a book can go through between two catalog versions
+const (
+	BookAdded   = "added"
+	BookUpdated = "updated"
+	BookRemoved = "removed"
+)
+
+// CatalogVersion represents the catalog as returned by the upstream API. Fetches
+// that return the same catalog are folded into a single version, LastSeenAt
+// tells when it was fetched for the last time.
+type CatalogVersion struct {
+	Version    int       `json:"version" example:"3"`
+	FetchedAt  time.Time `json:"fetched_at" example:"2025-01-10T12:00:00Z"`
+	LastSeenAt time.Time `json:"last_seen_at" example:"2025-01-10T18:00:00Z"`
+	Books      []Book    `json:"books"`
+}
+
+// BookChange represents how a book changed when a catalog version was recorded
+type BookChange struct {
+	Version   int       `json:"version" example:"3"`
+	ChangedAt time.Time `json:"changed_at" example:"2025-01-10T12:00:00Z"`
+	Type      string    `json:"type" example:"updated"`
+	Before    *Book     `json:"before,omitempty"`
+	After     *Book     `json:"after,omitempty"`
+}
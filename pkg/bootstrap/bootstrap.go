@@ -1,14 +1,286 @@
 package bootstrap
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"educabot.com/bookshop/pkg/requestid"
+)
+
+const (
+	defaultHistoryMaxVersions = 1000
+	defaultHistoryMaxAge      = 90 * 24 * time.Hour
+	defaultHistoryMaxBooks    = 1000000
+
+	defaultAnomalyPriceChangeThreshold = 0.5
+	defaultAnomalyZScoreThreshold      = 3
+	defaultAnomalyWindow               = 500
+	defaultAnomalyMaxFindings          = 1000
+	defaultAnomalyConfirmAfter         = 3
+
+	defaultBooksAPIMaxBytes = 64 << 20
+	defaultBooksAPIMaxItems = 500000
+
+	defaultBooksAPITokenRefreshBefore = time.Minute
+	defaultBooksAPIRateBurst          = 1
+	defaultBooksAPIHedgeDelay         = 200 * time.Millisecond
 )
 
-func InitLogger() *log.Logger {
-	return log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile)
+// InitLogger returns a logger writing JSON to stdout at the LOG_LEVEL level,
+// records logged with a request context carry its request ID
+func InitLogger() *slog.Logger {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     GetLogLevel(),
+	})
+	return slog.New(requestid.NewLogHandler(handler))
+}
+
+// GetLogLevel returns the minimum level logged: debug, info, warn or error
+func GetLogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
 }
 
 func GetBooksAPIURL() string {
 	return os.Getenv("BOOKS_API_URL")
-}
\ No newline at end of file
+}
+
+// GetBooksAPICassetteMode tells whether the books API traffic is recorded to
+// the cassette ("record"), served from it without network ("replay") or
+// left alone ("off")
+func GetBooksAPICassetteMode() string {
+	return os.Getenv("BOOKS_API_CASSETTE_MODE")
+}
+
+// GetBooksAPICassette returns the JSONL file the books API traffic is recorded to and replayed from
+func GetBooksAPICassette() string {
+	return os.Getenv("BOOKS_API_CASSETTE")
+}
+
+// GetBooksAPIMirrors returns the URLs serving the same catalog as BOOKS_API_URL, tried in order
+func GetBooksAPIMirrors() []string {
+	return getEnvList("BOOKS_API_MIRRORS")
+}
+
+// GetBooksAPIHedgeDelay returns how long a request to the books API runs before the next mirror is tried
+func GetBooksAPIHedgeDelay() time.Duration {
+	return getEnvDuration("BOOKS_API_HEDGE_DELAY", defaultBooksAPIHedgeDelay)
+}
+
+// GetBooksAPIAuth returns how requests to the books API authenticate: none,
+// api_key, bearer, basic, mtls or oauth2
+func GetBooksAPIAuth() string {
+	return os.Getenv("BOOKS_API_AUTH")
+}
+
+// GetBooksAPIKeyHeader returns the header carrying the API key
+func GetBooksAPIKeyHeader() string {
+	return os.Getenv("BOOKS_API_KEY_HEADER")
+}
+
+func GetBooksAPIKey() string {
+	return os.Getenv("BOOKS_API_KEY")
+}
+
+// GetBooksAPITokenFile returns the file holding the bearer token, read again when it changes
+func GetBooksAPITokenFile() string {
+	return os.Getenv("BOOKS_API_TOKEN_FILE")
+}
+
+func GetBooksAPIUsername() string {
+	return os.Getenv("BOOKS_API_USERNAME")
+}
+
+func GetBooksAPIPassword() string {
+	return os.Getenv("BOOKS_API_PASSWORD")
+}
+
+// GetBooksAPIClientCert returns the PEM client certificate presented for mTLS
+func GetBooksAPIClientCert() string {
+	return os.Getenv("BOOKS_API_CLIENT_CERT")
+}
+
+// GetBooksAPIClientKey returns the PEM private key of the mTLS client certificate
+func GetBooksAPIClientKey() string {
+	return os.Getenv("BOOKS_API_CLIENT_KEY")
+}
+
+// GetBooksAPICAFile returns the PEM CA bundle that verifies the books API in mTLS mode
+func GetBooksAPICAFile() string {
+	return os.Getenv("BOOKS_API_CA_FILE")
+}
+
+// GetBooksAPITokenURL returns the OAuth2 token endpoint used in oauth2 mode
+func GetBooksAPITokenURL() string {
+	return os.Getenv("BOOKS_API_TOKEN_URL")
+}
+
+func GetBooksAPIClientID() string {
+	return os.Getenv("BOOKS_API_CLIENT_ID")
+}
+
+func GetBooksAPIClientSecret() string {
+	return os.Getenv("BOOKS_API_CLIENT_SECRET")
+}
+
+// GetBooksAPIScopes returns the OAuth2 scopes requested with the token
+func GetBooksAPIScopes() []string {
+	return getEnvList("BOOKS_API_SCOPES")
+}
+
+// GetBooksAPITokenRefreshBefore returns how long before expiring OAuth2 tokens are renewed
+func GetBooksAPITokenRefreshBefore() time.Duration {
+	return getEnvDuration("BOOKS_API_TOKEN_REFRESH_BEFORE", defaultBooksAPITokenRefreshBefore)
+}
+
+// GetBooksAPIRateLimit returns the requests per second allowed to each upstream source, 0 disables the limit
+func GetBooksAPIRateLimit() float64 {
+	return getEnvFloat("BOOKS_API_RATE_LIMIT", 0)
+}
+
+// GetBooksAPIRateBurst returns how many requests can be sent at once before the rate limit applies
+func GetBooksAPIRateBurst() int {
+	return getEnvInt("BOOKS_API_RATE_BURST", defaultBooksAPIRateBurst)
+}
+
+// GetBooksAPISourceRateLimits returns per-source limits as host=rate:burst entries
+func GetBooksAPISourceRateLimits() []string {
+	return getEnvList("BOOKS_API_SOURCE_RATE_LIMITS")
+}
+
+// GetBooksAPIRateLimitPolicy tells whether rate limited calls "wait" for a token or "fail" right away
+func GetBooksAPIRateLimitPolicy() string {
+	if value := os.Getenv("BOOKS_API_RATE_LIMIT_POLICY"); value != "" {
+		return value
+	}
+	return "wait"
+}
+
+// GetBooksAPIMaxBytes returns the largest upstream response accepted, 0 disables the limit
+func GetBooksAPIMaxBytes() int64 {
+	return int64(getEnvInt("BOOKS_API_MAX_BYTES", defaultBooksAPIMaxBytes))
+}
+
+// GetBooksAPIMaxItems returns the most books accepted in an upstream response, 0 disables the limit
+func GetBooksAPIMaxItems() int {
+	return getEnvInt("BOOKS_API_MAX_ITEMS", defaultBooksAPIMaxItems)
+}
+
+// GetHistoryMaxVersions returns how many catalog versions the history keeps
+func GetHistoryMaxVersions() int {
+	return getEnvInt("HISTORY_MAX_VERSIONS", defaultHistoryMaxVersions)
+}
+
+// GetHistoryMaxAge returns how long catalog versions are kept in the history
+func GetHistoryMaxAge() time.Duration {
+	return getEnvDuration("HISTORY_MAX_AGE", defaultHistoryMaxAge)
+}
+
+// GetHistoryMaxBooks returns how many books the history keeps across every catalog version
+func GetHistoryMaxBooks() int {
+	return getEnvInt("HISTORY_MAX_BOOKS", defaultHistoryMaxBooks)
+}
+
+// GetAnomalyPriceChangeThreshold returns the relative price change flagged as an anomaly
+func GetAnomalyPriceChangeThreshold() float64 {
+	return getEnvFloat("ANOMALY_PRICE_CHANGE_THRESHOLD", defaultAnomalyPriceChangeThreshold)
+}
+
+// GetAnomalyZScoreThreshold returns the price change z-score flagged as an anomaly
+func GetAnomalyZScoreThreshold() float64 {
+	return getEnvFloat("ANOMALY_ZSCORE_THRESHOLD", defaultAnomalyZScoreThreshold)
+}
+
+// GetAnomalyWindow returns how many past price changes the z-scores are computed over
+func GetAnomalyWindow() int {
+	return getEnvInt("ANOMALY_WINDOW", defaultAnomalyWindow)
+}
+
+// GetAnomalyMaxFindings returns how many anomalies are kept for /admin/anomalies
+func GetAnomalyMaxFindings() int {
+	return getEnvInt("ANOMALY_MAX_FINDINGS", defaultAnomalyMaxFindings)
+}
+
+// GetAnomalyConfirmAfter returns how many fetches in a row a quarantined
+// record must keep its value before it is accepted
+func GetAnomalyConfirmAfter() int {
+	return getEnvInt("ANOMALY_CONFIRM_AFTER", defaultAnomalyConfirmAfter)
+}
+
+// GetAnomalyQuarantine tells whether anomalous records are held back from metrics
+func GetAnomalyQuarantine() bool {
+	return getEnvBool("ANOMALY_QUARANTINE", false)
+}
+
+// GetDataQualityRules returns the data quality rules to check, all of them when empty
+func GetDataQualityRules() []string {
+	return getEnvList("DATA_QUALITY_RULES")
+}
+
+// GetDataQualityCriticalRules returns the data quality rules whose failures are critical
+func GetDataQualityCriticalRules() []string {
+	return getEnvList("DATA_QUALITY_CRITICAL_RULES")
+}
+
+// GetDataQualityRejectCritical tells whether books failing critical rules are dropped
+func GetDataQualityRejectCritical() bool {
+	return getEnvBool("DATA_QUALITY_REJECT_CRITICAL", false)
+}
+
+// GetDecodeBadRecords returns what to do with upstream records that cannot be
+// decoded: "skip" drops them and "quarantine" also keeps their raw JSON
+func GetDecodeBadRecords() string {
+	if value := os.Getenv("DECODE_BAD_RECORDS"); value != "" {
+		return value
+	}
+	return "skip"
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
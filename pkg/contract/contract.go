@@ -0,0 +1,290 @@
+// Package contract pins the schema of the upstream books payload and reports
+// how recorded or live responses drift from it.
+package contract
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"educabot.com/bookshop/repositories"
+)
+
+// Kinds of drift between a payload and the schema
+const (
+	DriftNewField     = "new_field"
+	DriftMissingField = "missing_field"
+	DriftTypeChange   = "type_change"
+	DriftInvalidValue = "invalid_value"
+)
+
+//go:embed books.schema.json
+var booksSchema []byte
+
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// Schema is the subset of JSON Schema the upstream contract needs: type,
+// properties, required, additionalProperties, items, pattern and minimum
+type Schema struct {
+	Type                 schemaTypes        `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes accepts "type" as a single name or a list of names
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Drift represents a difference between the payload and the schema, found in
+// Records records. Example is the path of the first one.
+type Drift struct {
+	Kind     string `json:"kind"`
+	Field    string `json:"field"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Records  int    `json:"records"`
+	Example  string `json:"example"`
+}
+
+// Report represents the drift found in a payload
+type Report struct {
+	Records int     `json:"records"`
+	Drifts  []Drift `json:"drifts"`
+}
+
+// OK tells whether the payload matches the schema
+func (r Report) OK() bool {
+	return len(r.Drifts) == 0
+}
+
+func (r Report) String() string {
+	if r.OK() {
+		return fmt.Sprintf("%d records match the schema", r.Records)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d records, %d drifts:", r.Records, len(r.Drifts))
+	for _, drift := range r.Drifts {
+		fmt.Fprintf(&b, "\n  %s %s", drift.Kind, drift.Field)
+		if drift.Expected != "" || drift.Actual != "" {
+			fmt.Fprintf(&b, " (expected %s, got %s)", drift.Expected, drift.Actual)
+		}
+		fmt.Fprintf(&b, " in %d records, e.g. %s", drift.Records, drift.Example)
+	}
+	return b.String()
+}
+
+// BooksSchema returns the schema of the upstream books payload
+func BooksSchema() *Schema {
+	schema, err := LoadSchema(booksSchema)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func LoadSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+		s.pattern = pattern
+	}
+	for _, child := range s.Properties {
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate checks a JSON payload against the schema. Only a payload that is
+// not JSON returns an error.
+func (s *Schema) Validate(payload []byte) (Report, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return Report{}, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	v := &validator{drifts: map[driftKey]*Drift{}}
+	if items, ok := value.([]any); ok {
+		v.report.Records = len(items)
+	}
+	v.validate(s, value, "$", "")
+
+	v.report.Drifts = make([]Drift, 0, len(v.order))
+	for _, key := range v.order {
+		v.report.Drifts = append(v.report.Drifts, *v.drifts[key])
+	}
+	return v.report, nil
+}
+
+// CheckCassette validates the body of every successful response recorded in
+// a cassette, in the order they were recorded
+func (s *Schema) CheckCassette(path string) ([]Report, error) {
+	interactions, err := repositories.LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	var reports []Report
+	for i, interaction := range interactions {
+		if interaction.Response.Status < 200 || interaction.Response.Status > 299 {
+			continue
+		}
+		report, err := s.Validate([]byte(interaction.Response.Body))
+		if err != nil {
+			return nil, fmt.Errorf("interaction %d: %w", i, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+type driftKey struct {
+	kind, field, expected, actual string
+}
+
+type validator struct {
+	report Report
+	drifts map[driftKey]*Drift
+	order  []driftKey
+}
+
+// add records a drift, drifts of the same kind on the same field of
+// different records are counted together
+func (v *validator) add(kind, field, path, expected, actual string) {
+	key := driftKey{kind, field, expected, actual}
+	if drift, ok := v.drifts[key]; ok {
+		drift.Records++
+		return
+	}
+	v.drifts[key] = &Drift{Kind: kind, Field: field, Expected: expected, Actual: actual, Records: 1, Example: path}
+	v.order = append(v.order, key)
+}
+
+// validate checks value at path, field is path without the array indexes
+func (v *validator) validate(s *Schema, value any, path, field string) {
+	name := field
+	if name == "" {
+		name = "$"
+	}
+	actual := jsonType(value)
+	if len(s.Type) > 0 && !s.Type.accepts(actual) {
+		v.add(DriftTypeChange, name, path, strings.Join(s.Type, "|"), actual)
+		return
+	}
+
+	switch value := value.(type) {
+	case []any:
+		if s.Items == nil {
+			break
+		}
+		itemField := field
+		if field != "" {
+			itemField += "[]"
+		}
+		for i, item := range value {
+			v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), itemField)
+		}
+	case map[string]any:
+		for _, required := range s.Required {
+			if _, ok := value[required]; !ok {
+				v.add(DriftMissingField, join(field, required), path+"."+required, "", "")
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			child, ok := s.Properties[key]
+			switch {
+			case ok:
+				v.validate(child, value[key], path+"."+key, join(field, key))
+			case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				v.add(DriftNewField, join(field, key), path+"."+key, "", jsonType(value[key]))
+			}
+		}
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			v.add(DriftInvalidValue, name, path, "pattern "+s.Pattern, strconv.Quote(value))
+		}
+	case json.Number:
+		if n, err := value.Float64(); err == nil && s.Minimum != nil && n < *s.Minimum {
+			v.add(DriftInvalidValue, name, path, fmt.Sprintf("minimum %v", *s.Minimum), value.String())
+		}
+	}
+}
+
+func (t schemaTypes) accepts(actual string) bool {
+	return slices.Contains(t, actual) || (actual == "integer" && slices.Contains(t, "number"))
+}
+
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func join(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
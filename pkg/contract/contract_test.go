@@ -0,0 +1,141 @@
+package contract
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/fakeupstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayCassette is the cassette the contract test runs against, override it
+// with CONTRACT_CASSETTE to check a new recording
+const replayCassette = "../../repositories/testdata/books_cassette.jsonl"
+
+// TestContract_ReplayCassette pins the upstream schema against the recorded
+// traffic. The recording sends one price as a string, which the decoder
+// coerces, and that drift is expected until the upstream fixes it.
+func TestContract_ReplayCassette(t *testing.T) {
+	reports, err := BooksSchema().CheckCassette(replayCassette)
+
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, []Drift{
+		{Kind: DriftTypeChange, Field: "price", Expected: "integer", Actual: "string", Records: 1, Example: "$[4].price"},
+	}, reports[0].Drifts)
+}
+
+// TestContract_NewCassette checks a new recording when CONTRACT_CASSETTE is
+// set, go test ./pkg/contract/ -run Contract
+func TestContract_NewCassette(t *testing.T) {
+	path := os.Getenv("CONTRACT_CASSETTE")
+	if path == "" {
+		t.Skip("CONTRACT_CASSETTE not set")
+	}
+
+	reports, err := BooksSchema().CheckCassette(path)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, reports)
+	for i, report := range reports {
+		assert.True(t, report.OK(), "interaction %d: %s", i, report)
+	}
+}
+
+// TestContract_Live checks the upstream itself when CONTRACT_LIVE_URL is set
+func TestContract_Live(t *testing.T) {
+	url := os.Getenv("CONTRACT_LIVE_URL")
+	if url == "" {
+		t.Skip("CONTRACT_LIVE_URL not set")
+	}
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	report, err := BooksSchema().Validate(body)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.String())
+}
+
+// TestContract_FakeUpstream keeps the fake upstream in line with the real one
+func TestContract_FakeUpstream(t *testing.T) {
+	server := httptest.NewServer(fakeupstream.New(fakeupstream.Config{Books: []models.Book{
+		{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 10, Price: 20},
+	}}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + fakeupstream.BooksPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	report, err := BooksSchema().Validate(body)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.String())
+}
+
+func TestSchema_Validate_Drift(t *testing.T) {
+	payload := `[
+		{"id": "1", "name": "Renamed", "writer": "A", "units_sold": 10, "price": 20},
+		{"id": 2, "name": "Typed", "author": "B", "units_sold": "10", "price": 20.5},
+		{"id": "3", "name": "Renamed too", "writer": "C", "units_sold": -1, "price": 20, "isbn": "978"},
+		{"id": "x", "name": null, "author": "D", "units_sold": 1, "price": 1}
+	]`
+
+	report, err := BooksSchema().Validate([]byte(payload))
+
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, 4, report.Records)
+	assert.Equal(t, []Drift{
+		{Kind: DriftMissingField, Field: "author", Records: 2, Example: "$[0].author"},
+		{Kind: DriftNewField, Field: "writer", Actual: "string", Records: 2, Example: "$[0].writer"},
+		{Kind: DriftTypeChange, Field: "id", Expected: "string", Actual: "integer", Records: 1, Example: "$[1].id"},
+		{Kind: DriftTypeChange, Field: "price", Expected: "integer", Actual: "number", Records: 1, Example: "$[1].price"},
+		{Kind: DriftTypeChange, Field: "units_sold", Expected: "integer", Actual: "string", Records: 1, Example: "$[1].units_sold"},
+		{Kind: DriftNewField, Field: "isbn", Actual: "string", Records: 1, Example: "$[2].isbn"},
+		{Kind: DriftInvalidValue, Field: "units_sold", Expected: "minimum 0", Actual: "-1", Records: 1, Example: "$[2].units_sold"},
+		{Kind: DriftInvalidValue, Field: "id", Expected: "pattern ^[0-9]+$", Actual: `"x"`, Records: 1, Example: "$[3].id"},
+		{Kind: DriftTypeChange, Field: "name", Expected: "string", Actual: "null", Records: 1, Example: "$[3].name"},
+	}, report.Drifts)
+	assert.Contains(t, report.String(), "missing_field author in 2 records, e.g. $[0].author")
+}
+
+func TestSchema_Validate_Payloads(t *testing.T) {
+	report, err := BooksSchema().Validate([]byte(`[]`))
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, "0 records match the schema", report.String())
+
+	report, err = BooksSchema().Validate([]byte(`{"books": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Drift{{Kind: DriftTypeChange, Field: "$", Expected: "array", Actual: "object", Records: 1, Example: "$"}}, report.Drifts)
+
+	_, err = BooksSchema().Validate([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestLoadSchema(t *testing.T) {
+	schema, err := LoadSchema([]byte(`{"type": ["integer", "null"]}`))
+	require.NoError(t, err)
+	for payload, ok := range map[string]bool{"1": true, "null": true, "1.5": false, `"1"`: false} {
+		report, err := schema.Validate([]byte(payload))
+		require.NoError(t, err)
+		assert.Equal(t, ok, report.OK(), payload)
+	}
+
+	_, err = LoadSchema([]byte(`{"pattern": "("}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+	_, err = LoadSchema([]byte(`{"type": 1}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
@@ -0,0 +1,295 @@
+// Package fakeupstream serves a stand-in for the mockapi.io books API, for
+// local development and integration tests.
+package fakeupstream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// BooksPath is where the books collection is served, as in mockapi.io
+const BooksPath = "/api/v1/books"
+
+// Config configures a Server. Each request waits Latency, plus up to Jitter,
+// and fails with a 500 with probability ErrorRate.
+type Config struct {
+	Books     []models.Book
+	Latency   time.Duration
+	Jitter    time.Duration
+	ErrorRate float64
+	// RandomSeed makes latency jitter and injected errors reproducible
+	RandomSeed int64
+}
+
+// Book is a book as mockapi.io writes it, with the ID as a string
+type Book struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Author    string `json:"author"`
+	UnitsSold uint   `json:"units_sold"`
+	Price     uint   `json:"price"`
+}
+
+// bookInput represents the fields accepted on create and update, absent
+// fields are left as they are on update
+type bookInput struct {
+	Name      *string `json:"name"`
+	Author    *string `json:"author"`
+	UnitsSold *uint   `json:"units_sold"`
+	Price     *uint   `json:"price"`
+}
+
+type Server struct {
+	config  Config
+	handler http.Handler
+
+	mu     sync.Mutex
+	books  []models.Book
+	nextID uint
+	rng    *rand.Rand
+}
+
+func New(config Config) *Server {
+	s := &Server{
+		config: config,
+		books:  slices.Clone(config.Books),
+		rng:    rand.New(rand.NewSource(config.RandomSeed)),
+	}
+	for _, book := range s.books {
+		s.nextID = max(s.nextID, book.ID)
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery(), s.inject)
+	router.GET(BooksPath, s.listBooks)
+	router.POST(BooksPath, s.createBook)
+	router.GET(BooksPath+"/:id", s.getBook)
+	router.PUT(BooksPath+"/:id", s.updateBook)
+	router.DELETE(BooksPath+"/:id", s.deleteBook)
+	s.handler = router
+	return s
+}
+
+// LoadSeed reads books from a JSON array file, accepting the same variants as
+// the books repository such as IDs sent as strings
+func LoadSeed(path string) ([]models.Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeSeed(data)
+}
+
+// DecodeSeed decodes a JSON array of books, failing on any record that the
+// repository would have to drop
+func DecodeSeed(data []byte) ([]models.Book, error) {
+	books, report, err := repositories.DecodeBooks(bytes.NewReader(data), repositories.DecodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if report.Rejected == 0 {
+		return books, nil
+	}
+	for _, issue := range report.Issues {
+		if issue.Action != repositories.RecordCoerced {
+			return nil, fmt.Errorf("seed record %d: %s %s", issue.Index, issue.Field, issue.Message)
+		}
+	}
+	return nil, fmt.Errorf("%d seed records cannot be decoded", report.Rejected)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Books returns the current catalog
+func (s *Server) Books() []models.Book {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.books)
+}
+
+// inject applies the configured latency and error rate
+func (s *Server) inject(ctx *gin.Context) {
+	s.mu.Lock()
+	delay := s.config.Latency
+	if s.config.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(s.config.Jitter)))
+	}
+	fail := s.config.ErrorRate > 0 && s.rng.Float64() < s.config.ErrorRate
+	s.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Request.Context().Done():
+			ctx.Abort()
+			return
+		}
+	}
+	if fail {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, "Injected error")
+		return
+	}
+	ctx.Next()
+}
+
+// listBooks serves the whole catalog, or a page of it when page or limit
+// are given as mockapi.io does, with an ETag of the response body
+func (s *Server) listBooks(ctx *gin.Context) {
+	books := s.Books()
+
+	page, limit := 1, len(books)
+	var err error
+	if value := ctx.Query("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			ctx.JSON(http.StatusBadRequest, "Invalid page")
+			return
+		}
+		limit = 10
+	}
+	if value := ctx.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			ctx.JSON(http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+	// pages past the end are found without multiplying, so huge page
+	// numbers cannot overflow
+	start := len(books)
+	if len(books) > 0 && page-1 <= (len(books)-1)/limit {
+		start = (page - 1) * limit
+	}
+	end := start + min(limit, len(books)-start)
+
+	body, err := json.Marshal(toBooks(books[start:end]))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	ctx.Header("ETag", etag)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json", body)
+}
+
+func (s *Server) getBook(ctx *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, ok := s.find(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, toBook(s.books[index]))
+}
+
+func (s *Server) createBook(ctx *gin.Context) {
+	var input bookInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, "Invalid book")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	book := models.Book{ID: s.nextID}
+	input.apply(&book)
+	s.books = append(s.books, book)
+	ctx.JSON(http.StatusCreated, toBook(book))
+}
+
+func (s *Server) updateBook(ctx *gin.Context) {
+	var input bookInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, "Invalid book")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, ok := s.find(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	input.apply(&s.books[index])
+	ctx.JSON(http.StatusOK, toBook(s.books[index]))
+}
+
+func (s *Server) deleteBook(ctx *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, ok := s.find(ctx.Param("id"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, "Not found")
+		return
+	}
+	book := s.books[index]
+	s.books = slices.Delete(s.books, index, index+1)
+	ctx.JSON(http.StatusOK, toBook(book))
+}
+
+// find returns the position of the book with the given ID, s.mu must be held
+func (s *Server) find(id string) (int, bool) {
+	n, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return 0, false
+	}
+	index := slices.IndexFunc(s.books, func(book models.Book) bool { return book.ID == uint(n) })
+	return index, index >= 0
+}
+
+func (input bookInput) apply(book *models.Book) {
+	if input.Name != nil {
+		book.Name = *input.Name
+	}
+	if input.Author != nil {
+		book.Author = *input.Author
+	}
+	if input.UnitsSold != nil {
+		book.UnitsSold = *input.UnitsSold
+	}
+	if input.Price != nil {
+		book.Price = *input.Price
+	}
+}
+
+func toBook(book models.Book) Book {
+	return Book{
+		ID:        strconv.FormatUint(uint64(book.ID), 10),
+		Name:      book.Name,
+		Author:    book.Author,
+		UnitsSold: book.UnitsSold,
+		Price:     book.Price,
+	}
+}
+
+func toBooks(books []models.Book) []Book {
+	converted := make([]Book, len(books))
+	for i, book := range books {
+		converted[i] = toBook(book)
+	}
+	return converted
+}
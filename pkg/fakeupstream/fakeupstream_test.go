@@ -0,0 +1,187 @@
+package fakeupstream
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var seedBooks = []models.Book{
+	{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+	{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 200, Price: 30},
+	{ID: 3, Name: "Book 3", Author: "Author 1", UnitsSold: 300, Price: 40},
+}
+
+func serve(t *testing.T, server *Server, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, req)
+	return res
+}
+
+func decodeBooks(t *testing.T, res *httptest.ResponseRecorder) []Book {
+	var books []Book
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &books))
+	return books
+}
+
+func TestServer_ListBooks(t *testing.T) {
+	server := New(Config{Books: seedBooks})
+
+	res := serve(t, server, http.MethodGet, BooksPath, "", nil)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, []Book{
+		{ID: "1", Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+		{ID: "2", Name: "Book 2", Author: "Author 2", UnitsSold: 200, Price: 30},
+		{ID: "3", Name: "Book 3", Author: "Author 1", UnitsSold: 300, Price: 40},
+	}, decodeBooks(t, res))
+}
+
+func TestServer_Pagination(t *testing.T) {
+	server := New(Config{Books: seedBooks})
+
+	tests := []struct {
+		query string
+		ids   []string
+		code  int
+	}{
+		{"?page=1&limit=2", []string{"1", "2"}, http.StatusOK},
+		{"?page=2&limit=2", []string{"3"}, http.StatusOK},
+		{"?page=3&limit=2", []string{}, http.StatusOK},
+		{"?limit=1", []string{"1"}, http.StatusOK},
+		{"?page=2305843009213693953&limit=4", []string{}, http.StatusOK},
+		{"?page=1&limit=9223372036854775807", []string{"1", "2", "3"}, http.StatusOK},
+		{"?page=2&limit=9223372036854775807", []string{}, http.StatusOK},
+		{"?page=0", nil, http.StatusBadRequest},
+		{"?limit=abc", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		res := serve(t, server, http.MethodGet, BooksPath+tt.query, "", nil)
+
+		assert.Equal(t, tt.code, res.Code, tt.query)
+		if tt.code != http.StatusOK {
+			continue
+		}
+		ids := []string{}
+		for _, book := range decodeBooks(t, res) {
+			ids = append(ids, book.ID)
+		}
+		assert.Equal(t, tt.ids, ids, tt.query)
+	}
+}
+
+func TestServer_ETag(t *testing.T) {
+	server := New(Config{Books: seedBooks})
+
+	res := serve(t, server, http.MethodGet, BooksPath, "", nil)
+	etag := res.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	res = serve(t, server, http.MethodGet, BooksPath, "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	// any change to the catalog changes the ETag
+	serve(t, server, http.MethodPut, BooksPath+"/1", `{"price": 25}`, nil)
+	res = serve(t, server, http.MethodGet, BooksPath, "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotEqual(t, etag, res.Header().Get("ETag"))
+}
+
+func TestServer_CRUD(t *testing.T) {
+	server := New(Config{Books: seedBooks})
+
+	res := serve(t, server, http.MethodPost, BooksPath, `{"name": "Book 4", "author": "Author 4", "units_sold": 10, "price": 15}`, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.JSONEq(t, `{"id": "4", "name": "Book 4", "author": "Author 4", "units_sold": 10, "price": 15}`, res.Body.String())
+
+	res = serve(t, server, http.MethodGet, BooksPath+"/4", "", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = serve(t, server, http.MethodPut, BooksPath+"/2", `{"units_sold": 250}`, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"id": "2", "name": "Book 2", "author": "Author 2", "units_sold": 250, "price": 30}`, res.Body.String())
+
+	res = serve(t, server, http.MethodDelete, BooksPath+"/1", "", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	assert.Equal(t, []models.Book{
+		{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 250, Price: 30},
+		{ID: 3, Name: "Book 3", Author: "Author 1", UnitsSold: 300, Price: 40},
+		{ID: 4, Name: "Book 4", Author: "Author 4", UnitsSold: 10, Price: 15},
+	}, server.Books())
+
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodGet, BooksPath + "/1", ""},
+		{http.MethodPut, BooksPath + "/99", `{}`},
+		{http.MethodDelete, BooksPath + "/abc", ""},
+	} {
+		res := serve(t, server, tt.method, tt.target, tt.body, nil)
+		assert.Equal(t, http.StatusNotFound, res.Code, tt.method+" "+tt.target)
+	}
+
+	res = serve(t, server, http.MethodPost, BooksPath, `{"price": "free"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestServer_ErrorInjection(t *testing.T) {
+	server := New(Config{Books: seedBooks, ErrorRate: 0.3, RandomSeed: 42})
+
+	failures := 0
+	for i := 0; i < 1000; i++ {
+		if serve(t, server, http.MethodGet, BooksPath, "", nil).Code == http.StatusInternalServerError {
+			failures++
+		}
+	}
+	assert.InDelta(t, 300, failures, 50)
+
+	server = New(Config{Books: seedBooks, ErrorRate: 1})
+	assert.Equal(t, http.StatusInternalServerError, serve(t, server, http.MethodGet, BooksPath, "", nil).Code)
+}
+
+func TestServer_Latency(t *testing.T) {
+	server := New(Config{Books: seedBooks, Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond})
+
+	start := time.Now()
+	res := serve(t, server, http.MethodGet, BooksPath, "", nil)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestLoadSeed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "books.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id": "7", "name": "Seeded", "price": "12"}]`), 0o644))
+
+	books, err := LoadSeed(path)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Book{{ID: 7, Name: "Seeded", Price: 12}}, books)
+
+	// new books get IDs after the seeded ones
+	res := serve(t, New(Config{Books: books}), http.MethodPost, BooksPath, `{"name": "New"}`, nil)
+	assert.Contains(t, res.Body.String(), `"id":"8"`)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id": "x"}]`), 0o644))
+	_, err = LoadSeed(path)
+	assert.EqualError(t, err, "seed record 0: id not a number")
+
+	_, err = LoadSeed(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnmatchedRoute labels the requests no route matched, so unknown paths do
+// not create a series each
+const UnmatchedRoute = "unmatched"
+
+// Middleware counts the requests served by the router and observes their
+// latency, both by method, route template and status
+func Middleware(registry *Registry) gin.HandlerFunc {
+	requests := NewCounterVec(registry, "http_requests_total",
+		"HTTP requests served.", "method", "route", "status")
+	duration := NewHistogramVec(registry, "http_request_duration_seconds",
+		"Latency of the HTTP requests served.", nil, "method", "route", "status")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = UnmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.Inc(c.Request.Method, route, status)
+		duration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+}
+
+// Handler serves every metric of registry in the text exposition format
+func Handler(registry *Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var b strings.Builder
+		registry.WriteTo(&b)
+		c.Data(http.StatusOK, ContentType, []byte(b.String()))
+	}
+}
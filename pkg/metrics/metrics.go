@@ -0,0 +1,285 @@
+// Package metrics keeps counters, gauges and histograms and exposes them in
+// the Prometheus text format, without depending on the Prometheus client.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type collector interface {
+	name() string
+	write(b *strings.Builder)
+}
+
+// Registry holds the metrics exposed together on one endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds c, registering two metrics with the same name is a
+// programming error
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.collectors {
+		if existing.name() == c.name() {
+			panic(fmt.Sprintf("metrics: %s registered twice", c.name()))
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every metric in the text exposition format, in the order
+// they were registered
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, c := range collectors {
+		c.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// vec keeps one series per combination of label values
+type vec[S any] struct {
+	metric string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*S
+	values map[string][]string
+}
+
+func newVec[S any](name, help string, labels []string) vec[S] {
+	return vec[S]{
+		metric: name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*S),
+		values: make(map[string][]string),
+	}
+}
+
+func (v *vec[S]) name() string {
+	return v.metric
+}
+
+// with returns the series of values, creating it with create when missing.
+// v.mu must be held.
+func (v *vec[S]) with(values []string, create func() *S) *S {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.metric, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = create()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// sorted returns the series keys ordered by label values. v.mu must be held.
+func (v *vec[S]) sorted() []string {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec[S]) header(b *strings.Builder, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", v.metric, escapeHelp(v.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", v.metric, kind)
+}
+
+// CounterVec is a set of counters partitioned by labels
+type CounterVec struct {
+	vec[float64]
+}
+
+func NewCounterVec(registry *Registry, name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec[float64](name, help, labels)}
+	registry.register(c)
+	return c
+}
+
+// Inc adds one to the counter of values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative, to the counter of values
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: %s cannot decrease", c.metric))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(values, func() *float64 { return new(float64) }) += delta
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(b, "counter")
+	for _, key := range c.sorted() {
+		writeSample(b, c.metric, c.labels, c.values[key], "", "", *c.series[key])
+	}
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	vec[float64]
+}
+
+func NewGauge(registry *Registry, name, help string) *Gauge {
+	g := &Gauge{vec: newVec[float64](name, help, nil)}
+	g.Set(0)
+	registry.register(g)
+	return g
+}
+
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(nil, func() *float64 { return new(float64) }) = value
+}
+
+func (g *Gauge) write(b *strings.Builder) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(b, "gauge")
+	for _, key := range g.sorted() {
+		writeSample(b, g.metric, nil, nil, "", "", *g.series[key])
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a set of histograms partitioned by labels
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogramVec counts observations in buckets with the given upper
+// bounds, DefaultBuckets when nil
+func NewHistogramVec(registry *Registry, name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{vec: newVec[histogram](name, help, labels), buckets: buckets}
+	registry.register(h)
+	return h
+}
+
+// Observe adds value to the histogram of values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.with(values, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(b, "histogram")
+	for _, key := range h.sorted() {
+		s, values := h.series[key], h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(b, h.metric+"_bucket", h.labels, values, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(b, h.metric+"_bucket", h.labels, values, "le", "+Inf", float64(s.count))
+		writeSample(b, h.metric+"_sum", h.labels, values, "", "", s.sum)
+		writeSample(b, h.metric+"_count", h.labels, values, "", "", float64(s.count))
+	}
+}
+
+// writeSample writes one line, extra is an additional label such as le
+func writeSample(b *strings.Builder, name string, labels, values []string, extra, extraValue string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 || extra != "" {
+		b.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extra != "" {
+			if len(labels) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", extra, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func exposition(registry *Registry) string {
+	var b strings.Builder
+	registry.WriteTo(&b)
+	return b.String()
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounterVec(registry, "requests_total", "Requests\nserved.", "path")
+	gauge := NewGauge(registry, "catalog_size", "Books.")
+	histogram := NewHistogramVec(registry, "duration_seconds", "Latency.", []float64{1, 0.1}, "outcome")
+
+	counter.Inc(`/a"b`)
+	counter.Add(2, "/")
+	gauge.Set(42)
+	histogram.Observe(0.05, "ok")
+	histogram.Observe(0.1, "ok")
+	histogram.Observe(3, "ok")
+
+	expected := `# HELP requests_total Requests\nserved.
+# TYPE requests_total counter
+requests_total{path="/"} 2
+requests_total{path="/a\"b"} 1
+# HELP catalog_size Books.
+# TYPE catalog_size gauge
+catalog_size 42
+# HELP duration_seconds Latency.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{outcome="ok",le="0.1"} 2
+duration_seconds_bucket{outcome="ok",le="1"} 2
+duration_seconds_bucket{outcome="ok",le="+Inf"} 3
+duration_seconds_sum{outcome="ok"} 3.15
+duration_seconds_count{outcome="ok"} 3
+`
+	assert.Equal(t, expected, exposition(registry))
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounterVec(registry, "requests_total", "Requests.", "path")
+
+	assert.Panics(t, func() { NewGauge(registry, "requests_total", "Again.") })
+	assert.Panics(t, func() { counter.Inc() })
+	assert.Panics(t, func() { counter.Add(-1, "/") })
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := NewRegistry()
+	r := gin.New()
+	r.Use(Middleware(registry))
+	r.GET("/books/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/metrics", Handler(registry))
+
+	for _, path := range []string{"/books/1", "/books/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, ContentType, res.Header().Get("Content-Type"))
+	body := res.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/books/:id",status="204"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/books/:id",status="204"} 2`)
+}
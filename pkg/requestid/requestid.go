@@ -0,0 +1,96 @@
+// Package requestid gives every request a correlation ID and carries it
+// through the context, so the log lines and the calls to the upstream API a
+// request causes can be tied back to it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Header carries the ID on incoming requests, responses and upstream calls
+	Header = "X-Request-ID"
+	// LogKey is the attribute the ID is logged under
+	LogKey = "request_id"
+	// MaxLength bounds the IDs accepted from clients
+	MaxLength = 128
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID carried by ctx, or "" when there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a random 128-bit ID in hex
+func New() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// valid tells whether an ID sent by a client can be propagated as is: it must
+// be short and made of visible ASCII characters only
+func valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware keeps the X-Request-ID sent by the client, or assigns a new one
+// when it is missing or invalid, stores it in the request context and echoes
+// it in the response
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// LogHandler adds the request ID found in the context to every record
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps next so records logged with a context carry its request ID
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{Handler: next}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
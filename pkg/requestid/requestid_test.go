@@ -0,0 +1,79 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"propagates the client ID", "abc-123", true},
+		{"assigns an ID when missing", "", false},
+		{"replaces an ID with spaces", "abc 123", false},
+		{"replaces an ID that is too long", strings.Repeat("a", MaxLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.Use(Middleware())
+			r.GET("/", func(c *gin.Context) {
+				seen = FromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(Header, tt.incoming)
+			}
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
+
+			require.NotEmpty(t, seen)
+			assert.Equal(t, seen, res.Header().Get(Header))
+			if tt.keep {
+				assert.Equal(t, tt.incoming, seen)
+			} else {
+				assert.NotEqual(t, tt.incoming, seen)
+				assert.Len(t, seen, 32)
+			}
+		})
+	}
+}
+
+func TestFromContext_Missing(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+}
+
+func TestLogHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&out, nil))).With("component", "test")
+
+	logger.InfoContext(NewContext(context.Background(), "abc-123"), "with ID")
+	logger.Info("without ID")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "abc-123", first[LogKey])
+	assert.Equal(t, "test", first["component"])
+	assert.NotContains(t, second, LogKey)
+}
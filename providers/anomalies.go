@@ -0,0 +1,244 @@
+package providers
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+// Kinds of anomaly reported by AnomalyDetector
+const (
+	AnomalyPriceChange       = "price_change"
+	AnomalyUnitsSoldDecrease = "units_sold_decrease"
+	AnomalyVanishedID        = "vanished_id"
+	AnomalyDuplicateID       = "duplicate_id"
+)
+
+// minZScoreSamples is the number of past price changes needed before z-scores are trusted
+const minZScoreSamples = 10
+
+// Anomaly represents a suspicious change found when comparing two fetched catalogs
+type Anomaly struct {
+	Type        string       `json:"type" example:"price_change"`
+	BookID      uint         `json:"book_id" example:"1"`
+	DetectedAt  time.Time    `json:"detected_at" example:"2025-01-10T12:00:00Z"`
+	Message     string       `json:"message" example:"price changed from 40 to 0"`
+	ZScore      float64      `json:"z_score,omitempty" example:"4.2"`
+	Previous    *models.Book `json:"previous,omitempty"`
+	Current     *models.Book `json:"current,omitempty"`
+	Quarantined bool         `json:"quarantined" example:"true"`
+}
+
+// AnomalyDetectorConfig configures AnomalyDetector. PriceChangeThreshold is a
+// relative change, 0.5 flags prices that move by more than 50%. A quarantined
+// record that keeps the same value for ConfirmAfter fetches in a row is
+// accepted as the new baseline, 0 keeps it quarantined until it reverts.
+type AnomalyDetectorConfig struct {
+	PriceChangeThreshold float64
+	ZScoreThreshold      float64
+	Window               int
+	Quarantine           bool
+	ConfirmAfter         int
+	MaxFindings          int
+}
+
+// pendingChange is a quarantined record and the fetches in a row it was seen in
+type pendingChange struct {
+	book  models.Book
+	count int
+}
+
+// AnomalyDetector compares each fetched catalog with the previous one. Price
+// changes are flagged when they exceed the threshold or when their z-score
+// against a rolling window of past price changes does. When quarantine is on,
+// anomalous records are replaced by their previous version, or dropped for
+// duplicates, before they reach metrics. An anomaly that persists across
+// fetches is only reported the first time.
+type AnomalyDetector struct {
+	mu       sync.Mutex
+	config   AnomalyDetectorConfig
+	previous []models.Book
+	seen     bool
+	changes  []float64
+	findings []Anomaly
+	// pending holds the quarantined records by ID
+	pending map[uint]pendingChange
+	// duplicates holds the IDs repeated in the previous catalog
+	duplicates map[uint]struct{}
+}
+
+func NewAnomalyDetector(config AnomalyDetectorConfig) *AnomalyDetector {
+	return &AnomalyDetector{
+		config:     config,
+		pending:    make(map[uint]pendingChange),
+		duplicates: make(map[uint]struct{}),
+	}
+}
+
+// Inspect records the anomalies between books and the previously inspected
+// catalog and returns the books metrics should use along with the new findings
+func (d *AnomalyDetector) Inspect(books []models.Book, at time.Time) ([]models.Book, []Anomaly) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var findings []Anomaly
+	accepted := make([]models.Book, 0, len(books))
+	before := repositories.BooksByID(d.previous)
+	current := make(map[uint]struct{}, len(books))
+	duplicates := make(map[uint]struct{})
+
+	for _, book := range books {
+		if _, ok := current[book.ID]; ok {
+			duplicates[book.ID] = struct{}{}
+			if _, reported := d.duplicates[book.ID]; !reported {
+				findings = append(findings, Anomaly{
+					Type:        AnomalyDuplicateID,
+					BookID:      book.ID,
+					Message:     fmt.Sprintf("ID %d is repeated", book.ID),
+					Current:     &book,
+					Quarantined: d.config.Quarantine,
+				})
+			}
+			if !d.config.Quarantine {
+				accepted = append(accepted, book)
+			}
+			continue
+		}
+		current[book.ID] = struct{}{}
+
+		old, ok := before[book.ID]
+		if !ok {
+			accepted = append(accepted, book)
+			continue
+		}
+
+		bookFindings := d.compare(old, book, true)
+		if len(bookFindings) == 0 || !d.config.Quarantine {
+			delete(d.pending, book.ID)
+			accepted = append(accepted, book)
+			findings = append(findings, bookFindings...)
+			continue
+		}
+
+		pending, persists := d.pending[book.ID]
+		if persists && len(d.compare(pending.book, book, false)) == 0 {
+			pending.count++
+		} else {
+			pending.count = 1
+			findings = append(findings, bookFindings...)
+		}
+		pending.book = book
+		if d.config.ConfirmAfter > 0 && pending.count >= d.config.ConfirmAfter {
+			delete(d.pending, book.ID)
+			accepted = append(accepted, book)
+			continue
+		}
+		d.pending[book.ID] = pending
+		accepted = append(accepted, old)
+	}
+
+	if d.seen {
+		for _, old := range d.previous {
+			if _, ok := current[old.ID]; ok {
+				continue
+			}
+			current[old.ID] = struct{}{}
+			delete(d.pending, old.ID)
+			findings = append(findings, Anomaly{
+				Type:     AnomalyVanishedID,
+				BookID:   old.ID,
+				Message:  fmt.Sprintf("ID %d is no longer in the catalog", old.ID),
+				Previous: &old,
+			})
+		}
+	}
+
+	for i := range findings {
+		findings[i].DetectedAt = at
+	}
+	d.findings = append(d.findings, findings...)
+	if d.config.MaxFindings > 0 && len(d.findings) > d.config.MaxFindings {
+		d.findings = slices.Delete(d.findings, 0, len(d.findings)-d.config.MaxFindings)
+	}
+	d.previous = accepted
+	d.duplicates = duplicates
+	d.seen = true
+	return accepted, findings
+}
+
+// Findings returns the retained anomalies, oldest first
+func (d *AnomalyDetector) Findings() []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.findings)
+}
+
+// compare returns the anomalies between old and book, observe adds the
+// accepted price changes to the z-score window
+func (d *AnomalyDetector) compare(old, book models.Book, observe bool) []Anomaly {
+	var findings []Anomaly
+
+	if book.UnitsSold < old.UnitsSold {
+		findings = append(findings, Anomaly{
+			Type:        AnomalyUnitsSoldDecrease,
+			BookID:      book.ID,
+			Message:     fmt.Sprintf("units sold went from %d to %d", old.UnitsSold, book.UnitsSold),
+			Previous:    &old,
+			Current:     &book,
+			Quarantined: d.config.Quarantine,
+		})
+	}
+
+	if book.Price != old.Price {
+		change := relativeChange(old.Price, book.Price)
+		z := d.zScore(change)
+		if math.Abs(change) > d.config.PriceChangeThreshold || math.Abs(z) > d.config.ZScoreThreshold {
+			findings = append(findings, Anomaly{
+				Type:        AnomalyPriceChange,
+				BookID:      book.ID,
+				Message:     fmt.Sprintf("price changed from %d to %d", old.Price, book.Price),
+				ZScore:      z,
+				Previous:    &old,
+				Current:     &book,
+				Quarantined: d.config.Quarantine,
+			})
+		} else if observe {
+			d.observe(change)
+		}
+	}
+
+	return findings
+}
+
+// zScore compares change with the rolling window of accepted price changes.
+// It returns 0 until the window holds enough samples.
+func (d *AnomalyDetector) zScore(change float64) float64 {
+	if len(d.changes) < minZScoreSamples || math.IsInf(change, 0) {
+		return 0
+	}
+	mean, stddev := meanAndStdDev(d.changes)
+	if stddev == 0 {
+		return 0
+	}
+	return (change - mean) / stddev
+}
+
+func (d *AnomalyDetector) observe(change float64) {
+	d.changes = append(d.changes, change)
+	if d.config.Window > 0 && len(d.changes) > d.config.Window {
+		d.changes = slices.Delete(d.changes, 0, len(d.changes)-d.config.Window)
+	}
+}
+
+// relativeChange returns +Inf when a price moves away from zero
+func relativeChange(old, current uint) float64 {
+	if old == 0 {
+		return math.Inf(1)
+	}
+	return (float64(current) - float64(old)) / float64(old)
+}
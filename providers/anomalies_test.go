@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var anomalyConfig = AnomalyDetectorConfig{
+	PriceChangeThreshold: 0.5,
+	ZScoreThreshold:      3,
+	Window:               100,
+	MaxFindings:          100,
+}
+
+func anomalyTypes(findings []Anomaly) []string {
+	types := make([]string, len(findings))
+	for i, finding := range findings {
+		types[i] = finding.Type
+	}
+	return types
+}
+
+func TestAnomalyDetector_FirstCatalog(t *testing.T) {
+	detector := NewAnomalyDetector(anomalyConfig)
+
+	books, findings := detector.Inspect([]models.Book{{ID: 1, Price: 10}, {ID: 2, Price: 0}}, seriesStart)
+
+	assert.Len(t, books, 2)
+	assert.Empty(t, findings)
+}
+
+func TestAnomalyDetector_Findings(t *testing.T) {
+	detector := NewAnomalyDetector(anomalyConfig)
+	detector.Inspect([]models.Book{
+		{ID: 1, Name: "Price drop", Price: 40, UnitsSold: 100},
+		{ID: 2, Name: "Backwards", Price: 20, UnitsSold: 500},
+		{ID: 3, Name: "Vanishing", Price: 30, UnitsSold: 10},
+		{ID: 4, Name: "Small change", Price: 100, UnitsSold: 10},
+	}, seriesStart)
+
+	books, findings := detector.Inspect([]models.Book{
+		{ID: 1, Name: "Price drop", Price: 0, UnitsSold: 120},
+		{ID: 2, Name: "Backwards", Price: 20, UnitsSold: 300},
+		{ID: 4, Name: "Small change", Price: 110, UnitsSold: 10},
+		{ID: 5, Name: "Duplicate A", Price: 10},
+		{ID: 5, Name: "Duplicate B", Price: 10},
+	}, seriesStart.Add(time.Hour))
+
+	assert.Equal(t, []string{AnomalyPriceChange, AnomalyUnitsSoldDecrease, AnomalyDuplicateID, AnomalyVanishedID}, anomalyTypes(findings))
+	assert.Equal(t, uint(1), findings[0].BookID)
+	assert.Equal(t, "price changed from 40 to 0", findings[0].Message)
+	assert.Equal(t, uint(2), findings[1].BookID)
+	assert.Equal(t, uint(5), findings[2].BookID)
+	assert.Equal(t, uint(3), findings[3].BookID)
+	for _, finding := range findings {
+		assert.Equal(t, seriesStart.Add(time.Hour), finding.DetectedAt)
+		assert.False(t, finding.Quarantined)
+	}
+
+	// without quarantine every record goes through
+	assert.Len(t, books, 5)
+	assert.Equal(t, uint(0), books[0].Price)
+	assert.Len(t, detector.Findings(), 4)
+}
+
+func TestAnomalyDetector_Quarantine(t *testing.T) {
+	config := anomalyConfig
+	config.Quarantine = true
+	detector := NewAnomalyDetector(config)
+	detector.Inspect([]models.Book{
+		{ID: 1, Price: 40, UnitsSold: 100},
+		{ID: 2, Price: 20, UnitsSold: 500},
+	}, seriesStart)
+
+	books, findings := detector.Inspect([]models.Book{
+		{ID: 1, Price: 0, UnitsSold: 100},
+		{ID: 2, Price: 21, UnitsSold: 600},
+		{ID: 2, Price: 99, UnitsSold: 600},
+	}, seriesStart.Add(time.Hour))
+
+	assert.Equal(t, []string{AnomalyPriceChange, AnomalyDuplicateID}, anomalyTypes(findings))
+	assert.True(t, findings[0].Quarantined)
+	assert.True(t, findings[1].Quarantined)
+	assert.Equal(t, []models.Book{
+		{ID: 1, Price: 40, UnitsSold: 100},
+		{ID: 2, Price: 21, UnitsSold: 600},
+	}, books)
+
+	// the next catalog is compared with the accepted one
+	_, findings = detector.Inspect([]models.Book{
+		{ID: 1, Price: 40, UnitsSold: 110},
+		{ID: 2, Price: 21, UnitsSold: 600},
+	}, seriesStart.Add(2*time.Hour))
+	assert.Empty(t, findings)
+}
+
+func TestAnomalyDetector_QuarantineConfirmsPersistentChange(t *testing.T) {
+	config := anomalyConfig
+	config.Quarantine = true
+	config.ConfirmAfter = 3
+	detector := NewAnomalyDetector(config)
+	detector.Inspect([]models.Book{{ID: 1, Price: 40, UnitsSold: 100}}, seriesStart)
+
+	// upstream keeps the new price while units sold keep growing
+	var reported []Anomaly
+	for i := 1; i <= 2; i++ {
+		books, findings := detector.Inspect([]models.Book{{ID: 1, Price: 10, UnitsSold: 100 + uint(i)}}, seriesStart.Add(time.Duration(i)*time.Hour))
+		assert.Equal(t, []models.Book{{ID: 1, Price: 40, UnitsSold: 100}}, books)
+		reported = append(reported, findings...)
+	}
+	assert.Equal(t, []string{AnomalyPriceChange}, anomalyTypes(reported))
+
+	// the third fetch in a row confirms it as the new baseline
+	books, findings := detector.Inspect([]models.Book{{ID: 1, Price: 10, UnitsSold: 103}}, seriesStart.Add(3*time.Hour))
+	assert.Empty(t, findings)
+	assert.Equal(t, []models.Book{{ID: 1, Price: 10, UnitsSold: 103}}, books)
+
+	books, findings = detector.Inspect([]models.Book{{ID: 1, Price: 10, UnitsSold: 104}}, seriesStart.Add(4*time.Hour))
+	assert.Empty(t, findings)
+	assert.Equal(t, []models.Book{{ID: 1, Price: 10, UnitsSold: 104}}, books)
+	assert.Len(t, detector.Findings(), 1)
+}
+
+func TestAnomalyDetector_QuarantineNewSuspiciousValue(t *testing.T) {
+	config := anomalyConfig
+	config.Quarantine = true
+	config.ConfirmAfter = 2
+	detector := NewAnomalyDetector(config)
+	detector.Inspect([]models.Book{{ID: 1, Price: 40}}, seriesStart)
+
+	_, findings := detector.Inspect([]models.Book{{ID: 1, Price: 10}}, seriesStart.Add(time.Hour))
+	assert.Len(t, findings, 1)
+
+	// a different value starts over and is reported again
+	books, findings := detector.Inspect([]models.Book{{ID: 1, Price: 100}}, seriesStart.Add(2*time.Hour))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, []models.Book{{ID: 1, Price: 40}}, books)
+}
+
+func TestAnomalyDetector_PersistentDuplicateReportedOnce(t *testing.T) {
+	detector := NewAnomalyDetector(anomalyConfig)
+	catalog := []models.Book{{ID: 1, Name: "A"}, {ID: 1, Name: "B"}}
+
+	_, findings := detector.Inspect(catalog, seriesStart)
+	assert.Equal(t, []string{AnomalyDuplicateID}, anomalyTypes(findings))
+	_, findings = detector.Inspect(catalog, seriesStart.Add(time.Hour))
+	assert.Empty(t, findings)
+
+	// once fixed, a new repetition is reported again
+	detector.Inspect(catalog[:1], seriesStart.Add(2*time.Hour))
+	_, findings = detector.Inspect(catalog, seriesStart.Add(3*time.Hour))
+	assert.Equal(t, []string{AnomalyDuplicateID}, anomalyTypes(findings))
+}
+
+func TestAnomalyDetector_ZScore(t *testing.T) {
+	detector := NewAnomalyDetector(anomalyConfig)
+	price := uint(1000)
+	detector.Inspect([]models.Book{{ID: 1, Price: price}}, seriesStart)
+
+	// a steady history of 1% and 2% increases
+	for i := 0; i < minZScoreSamples; i++ {
+		if i%2 == 0 {
+			price += price / 100
+		} else {
+			price += price / 50
+		}
+		_, findings := detector.Inspect([]models.Book{{ID: 1, Price: price}}, seriesStart.Add(time.Duration(i+1)*time.Hour))
+		assert.Empty(t, findings)
+	}
+
+	// a 30% increase is below the threshold but far outside the usual changes
+	price += price * 30 / 100
+	_, findings := detector.Inspect([]models.Book{{ID: 1, Price: price}}, seriesStart.Add(24*time.Hour))
+
+	assert.Equal(t, []string{AnomalyPriceChange}, anomalyTypes(findings))
+	assert.Greater(t, findings[0].ZScore, 3.0)
+}
+
+func TestAnomalyDetector_MaxFindings(t *testing.T) {
+	config := anomalyConfig
+	config.MaxFindings = 2
+	detector := NewAnomalyDetector(config)
+
+	detector.Inspect([]models.Book{{ID: 1}, {ID: 1}, {ID: 1}, {ID: 1}}, seriesStart)
+
+	assert.Len(t, detector.Findings(), 2)
+}
+
+func TestBooksProvider_GetBooks_QuarantinesAnomalies(t *testing.T) {
+	config := anomalyConfig
+	config.Quarantine = true
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{{ID: 1, Name: "Book 1", Price: 40, UnitsSold: 100}},
+	}
+
+	provider := &booksProvider{
+		repo:      mockRepo,
+		anomalies: NewAnomalyDetector(config),
+		logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	provider.GetBooks(context.Background(), BooksFilter{})
+	mockRepo.books = []models.Book{{ID: 1, Name: "Book 1", Price: 0, UnitsSold: 100}}
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Fields: []string{"price_stats"}, Stats: []string{StatMin}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Distribution{StatMin: 40}, metrics.Value("price_stats"))
+	assert.Len(t, provider.GetAnomalies(), 1)
+}
+
+func TestBooksProvider_GetAnomalies_NoDetector(t *testing.T) {
+	provider := &booksProvider{}
+
+	assert.Equal(t, []Anomaly{}, provider.GetAnomalies())
+}
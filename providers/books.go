@@ -1,80 +1,426 @@
 package providers
 
 import (
+	"bytes"
+	"cmp"
 	"context"
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"slices"
+	"time"
 
 	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/bootstrap"
 	"educabot.com/bookshop/repositories"
 )
 
-// BooksMetrics represents statistical metrics about books
+// BooksMetrics represents statistical metrics about books. Every computed
+// metric is written under its name, in the order the metrics were set,
+// followed by the filter and the number of books it matched.
 type BooksMetrics struct {
+	Filter       BooksFilter `json:"filter"`
+	MatchedBooks int         `json:"matched_books" example:"3"`
+
+	names  []string
+	values map[string]any
+}
+
+// Set stores the value of the named metric
+func (m *BooksMetrics) Set(name string, value any) {
+	if m.values == nil {
+		m.values = make(map[string]any)
+	}
+	if _, ok := m.values[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.values[name] = value
+}
+
+// Value returns the value of the named metric, nil when it was not computed
+func (m *BooksMetrics) Value(name string) any {
+	return m.values[name]
+}
+
+func (m BooksMetrics) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for _, name := range m.names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[name])
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", name, err)
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+		b.WriteByte(',')
+	}
+
+	type plainMetrics BooksMetrics
+	rest, err := json.Marshal(plainMetrics(m))
+	if err != nil {
+		return nil, err
+	}
+	b.Write(rest[1:])
+	return b.Bytes(), nil
+}
+
+// MetricsOptions represents the parameters of a metrics request
+type MetricsOptions struct {
+	Author string
+	Stats  []string
+	// Fields names the metrics to compute, the registry defaults are used when empty
+	Fields []string
+	// Filter narrows down the books the metrics are computed over
+	Filter BooksFilter
+}
+
+// AuthorMetrics represents statistical metrics about the books of a single author
+type AuthorMetrics struct {
+	Found                bool   `json:"found" example:"true"`
+	BooksWrittenByAuthor uint   `json:"books_written_by_author" example:"2"`
 	MeanUnitsSold        uint   `json:"mean_units_sold" example:"10000"`
 	CheapestBook         string `json:"cheapest_book" example:"The Go Programming Language"`
-	BooksWrittenByAuthor uint   `json:"books_written_by_author" example:"2"`
 }
 
+// MaxBatchAuthors is the maximum number of authors accepted by a single batch metrics request
+const MaxBatchAuthors = 25
+
+var (
+	ErrNoAuthors      = errors.New("at least one author is required")
+	ErrTooManyAuthors = errors.New("too many authors requested")
+	ErrUnknownMetric  = errors.New("unknown metric")
+	// ErrCatalogUnavailable is returned when the books could not be fetched
+	// and an empty catalog would give a wrong answer
+	ErrCatalogUnavailable = errors.New("books catalog unavailable")
+)
+
 type BooksProvider interface {
-	GetBooks(ctx context.Context) []models.Book
-	GetMetrics(ctx context.Context, author string) (*BooksMetrics, error)
+	GetBooks(ctx context.Context, filter BooksFilter) []models.Book
+	GetMetrics(ctx context.Context, opts MetricsOptions) (*BooksMetrics, error)
+	GetMetricsBatch(ctx context.Context, authors []string) (map[string]AuthorMetrics, error)
+	GetRevenueMetrics(ctx context.Context, top int) (*RevenueMetrics, error)
+	GetHistogram(ctx context.Context, opts HistogramOptions) (*Histogram, error)
+	GetConcentrationMetrics(ctx context.Context) (*ConcentrationMetrics, error)
+	GetMetricsCatalog() []Metric
+	GetBookHistory(id uint) (*BookHistory, error)
+	GetTimeSeries(opts TimeSeriesOptions) (*TimeSeries, error)
+	GetTrending(opts TrendingOptions) (*Trending, error)
+	GetAnomalies() []Anomaly
+	GetDataQuality(ctx context.Context) (*DataQualityReport, error)
+	GetRateLimits() []repositories.RateLimitStats
+	GetMirrors() []repositories.MirrorStats
+	GetBookForecast(id uint, opts ForecastOptions) (*Forecast, error)
+	GetAuthorForecast(author string, opts ForecastOptions) (*Forecast, error)
 }
 
 type booksProvider struct {
-	repo   repositories.BooksRepository
-	logger *log.Logger
+	repo      repositories.BooksRepository
+	history   repositories.HistoryRepository
+	anomalies *AnomalyDetector
+	quality   *DataQualityValidator
+	logger    *slog.Logger
 }
 
-func NewBooksProvider(logger *log.Logger) BooksProvider {
-	return &booksProvider{
-		repo:   repositories.NewHTTPBooksRepository(logger),
-		logger: logger,
+// Option customizes the provider built by NewBooksProvider
+type Option func(*booksProvider)
+
+// WithBooksRepository replaces the HTTP repository the catalog is fetched
+// from, such as with a decorated one
+func WithBooksRepository(repo repositories.BooksRepository) Option {
+	return func(p *booksProvider) {
+		p.repo = repo
 	}
 }
 
-func (p *booksProvider) GetBooks(ctx context.Context) []models.Book {
-	books, err := p.repo.GetBooks(ctx)
+// NewBooksProvider builds the provider configured through bootstrap, it fails
+// when the data quality rules are invalid
+func NewBooksProvider(logger *slog.Logger, opts ...Option) (BooksProvider, error) {
+	quality, err := NewDataQualityValidator(DataQualityConfig{
+		Rules:          bootstrap.GetDataQualityRules(),
+		Critical:       bootstrap.GetDataQualityCriticalRules(),
+		RejectCritical: bootstrap.GetDataQualityRejectCritical(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid data quality configuration: %w", err)
+	}
+
+	p := &booksProvider{
+		history: repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{
+			MaxVersions: bootstrap.GetHistoryMaxVersions(),
+			MaxAge:      bootstrap.GetHistoryMaxAge(),
+			MaxBooks:    bootstrap.GetHistoryMaxBooks(),
+		}),
+		anomalies: NewAnomalyDetector(AnomalyDetectorConfig{
+			PriceChangeThreshold: bootstrap.GetAnomalyPriceChangeThreshold(),
+			ZScoreThreshold:      bootstrap.GetAnomalyZScoreThreshold(),
+			Window:               bootstrap.GetAnomalyWindow(),
+			Quarantine:           bootstrap.GetAnomalyQuarantine(),
+			ConfirmAfter:         bootstrap.GetAnomalyConfirmAfter(),
+			MaxFindings:          bootstrap.GetAnomalyMaxFindings(),
+		}),
+		quality: quality,
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.repo == nil {
+		p.repo = repositories.NewHTTPBooksRepository(logger)
+	}
+	return p, nil
+}
+
+func (p *booksProvider) GetBooks(ctx context.Context, filter BooksFilter) []models.Book {
+	books, err := p.catalog(ctx)
 	if err != nil {
-		p.logger.Printf("Error fetching books: %v", err)
 		return []models.Book{}
 	}
-	return books
+	return filter.Apply(books)
 }
 
-func (p *booksProvider) GetMetrics(ctx context.Context, author string) (*BooksMetrics, error) {
-	books := p.GetBooks(ctx)
+// catalog fetches the books and runs them through the quality checks,
+// anomaly detection and history
+func (p *booksProvider) catalog(ctx context.Context) ([]models.Book, error) {
+	books, err := p.repo.GetBooks(ctx)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "Error fetching books", "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrCatalogUnavailable, err)
+	}
 
-	if len(books) == 0 {
-		return &BooksMetrics{}, nil
+	now := time.Now()
+	if p.quality != nil {
+		var report DataQualityReport
+		books, report = p.quality.Validate(books, now)
+		if report.RejectedBooks > 0 {
+			p.logger.WarnContext(ctx, "Rejected books failing critical data quality rules", "rejected", report.RejectedBooks)
+		}
+	}
+	if p.anomalies != nil {
+		var findings []Anomaly
+		books, findings = p.anomalies.Inspect(books, now)
+		for _, finding := range findings {
+			p.logger.WarnContext(ctx, "Catalog anomaly",
+				"type", finding.Type,
+				"book_id", finding.BookID,
+				"message", finding.Message,
+				"quarantined", finding.Quarantined,
+			)
+		}
 	}
+	if p.history != nil {
+		p.history.Record(books, now)
+	}
+	return books, nil
+}
 
-	meanUnitsSold := p.meanUnitsSold(books)
-	cheapestBook := p.cheapestBook(books)
-	booksWrittenByAuthor := p.booksWrittenByAuthor(books, author)
+func (p *booksProvider) GetAnomalies() []Anomaly {
+	if p.anomalies == nil {
+		return []Anomaly{}
+	}
+	return p.anomalies.Findings()
+}
 
-	return &BooksMetrics{
-		MeanUnitsSold:        meanUnitsSold,
-		CheapestBook:         cheapestBook.Name,
-		BooksWrittenByAuthor: booksWrittenByAuthor,
-	}, nil
+// GetDataQuality fetches the catalog and returns the data quality report of
+// the latest one validated, which is older when the fetch fails
+func (p *booksProvider) GetDataQuality(ctx context.Context) (*DataQualityReport, error) {
+	if p.quality == nil {
+		return nil, ErrNoQualityReport
+	}
+	p.GetBooks(ctx, BooksFilter{})
+	report, err := p.quality.Report()
+	if err != nil {
+		return nil, err
+	}
+	if reporter, ok := p.repo.(repositories.DecodeReporter); ok {
+		if decode, ok := reporter.LastDecodeReport(); ok {
+			report.Decode = &decode
+		}
+	}
+	return report, nil
+}
+
+// GetRateLimits returns the outgoing call stats of the repository, empty when it is not rate limited
+func (p *booksProvider) GetRateLimits() []repositories.RateLimitStats {
+	reporter, ok := p.repo.(repositories.RateLimitReporter)
+	if !ok {
+		return []repositories.RateLimitStats{}
+	}
+	return reporter.RateLimitStats()
 }
 
-func (p *booksProvider) meanUnitsSold(books []models.Book) uint {
-	var sum uint
+// GetMirrors returns the hedged request stats of the repository, empty when it does not hedge
+func (p *booksProvider) GetMirrors() []repositories.MirrorStats {
+	reporter, ok := p.repo.(repositories.MirrorReporter)
+	if !ok {
+		return []repositories.MirrorStats{}
+	}
+	return reporter.MirrorStats()
+}
+
+func (p *booksProvider) GetMetrics(ctx context.Context, opts MetricsOptions) (*BooksMetrics, error) {
+	if err := validateStats(opts.Stats); err != nil {
+		return nil, err
+	}
+	if err := opts.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	names := opts.Fields
+	if len(names) == 0 {
+		names = DefaultMetricRegistry.Defaults()
+		if len(opts.Stats) > 0 {
+			names = append(names, "units_sold_stats", "price_stats")
+		}
+	}
+	calculators, err := DefaultMetricRegistry.Lookup(names)
+	if err != nil {
+		return nil, err
+	}
+
+	books := p.GetBooks(ctx, opts.Filter)
+	metrics := &BooksMetrics{
+		Filter:       opts.Filter,
+		MatchedBooks: len(books),
+	}
+	for _, metric := range calculators {
+		switch {
+		case len(books) > 0:
+			metrics.Set(metric.Name, metric.Calculate(books, opts))
+		case metric.Empty != nil:
+			metrics.Set(metric.Name, metric.Empty)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *booksProvider) GetMetricsCatalog() []Metric {
+	return DefaultMetricRegistry.Metrics()
+}
+
+func (p *booksProvider) GetMetricsBatch(ctx context.Context, authors []string) (map[string]AuthorMetrics, error) {
+	authors = uniqueAuthors(authors)
+	if len(authors) == 0 {
+		return nil, ErrNoAuthors
+	}
+	if len(authors) > MaxBatchAuthors {
+		return nil, ErrTooManyAuthors
+	}
+
+	books, err := p.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byAuthor := make(map[string][]models.Book, len(authors))
 	for _, book := range books {
-		sum += book.UnitsSold
+		byAuthor[book.Author] = append(byAuthor[book.Author], book)
+	}
+
+	metrics := make(map[string]AuthorMetrics, len(authors))
+	for _, author := range authors {
+		books := byAuthor[author]
+		if len(books) == 0 {
+			metrics[author] = AuthorMetrics{Found: false}
+			continue
+		}
+		metrics[author] = AuthorMetrics{
+			Found:                true,
+			BooksWrittenByAuthor: uint(len(books)),
+			MeanUnitsSold:        meanUnitsSold(books),
+			CheapestBook:         cheapestBooks(books)[0].Name,
+		}
 	}
-	return sum / uint(len(books))
+	return metrics, nil
 }
 
-func (p *booksProvider) cheapestBook(books []models.Book) models.Book {
-	return slices.MinFunc(books, func(a, b models.Book) int {
-		return int(a.Price - b.Price)
+// uniqueAuthors drops empty and repeated author names, keeping the first occurrence order
+func uniqueAuthors(authors []string) []string {
+	seen := make(map[string]struct{}, len(authors))
+	unique := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if author == "" {
+			continue
+		}
+		if _, ok := seen[author]; ok {
+			continue
+		}
+		seen[author] = struct{}{}
+		unique = append(unique, author)
+	}
+	return unique
+}
+
+// meanUnitsSold divides each value before adding it up so the sum cannot
+// overflow, then adds back the mean of the remainders
+func meanUnitsSold(books []models.Book) uint {
+	count := uint(len(books))
+	var mean, remainders uint
+	for _, book := range books {
+		mean += book.UnitsSold / count
+		remainders += book.UnitsSold % count
+	}
+	return mean + remainders/count
+}
+
+func cheapestBooks(books []models.Book) []models.Book {
+	return tiedBooks(books, bookPrice, -1)
+}
+
+func mostExpensiveBooks(books []models.Book) []models.Book {
+	return tiedBooks(books, bookPrice, 1)
+}
+
+func bestSellers(books []models.Book) []models.Book {
+	return tiedBooks(books, bookUnitsSold, 1)
+}
+
+func worstSellers(books []models.Book) []models.Book {
+	return tiedBooks(books, bookUnitsSold, -1)
+}
+
+func bookPrice(book models.Book) uint     { return book.Price }
+func bookUnitsSold(book models.Book) uint { return book.UnitsSold }
+
+// tiedBooks returns every book holding the extreme value of key, the minimum
+// when direction is negative and the maximum otherwise, ordered by ID and name
+func tiedBooks(books []models.Book, key func(models.Book) uint, direction int) []models.Book {
+	var tied []models.Book
+	for _, book := range books {
+		if len(tied) == 0 {
+			tied = append(tied, book)
+			continue
+		}
+		switch c := cmp.Compare(key(book), key(tied[0])); {
+		case c == 0:
+			tied = append(tied, book)
+		case (c < 0) == (direction < 0):
+			tied = append(tied[:0], book)
+		}
+	}
+
+	slices.SortFunc(tied, func(a, b models.Book) int {
+		if c := cmp.Compare(a.ID, b.ID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
 	})
+	return tied
+}
+
+func bookNames(books []models.Book) []string {
+	names := make([]string, len(books))
+	for i, book := range books {
+		names[i] = book.Name
+	}
+	return names
 }
 
-func (p *booksProvider) booksWrittenByAuthor(books []models.Book, author string) uint {
+func booksWrittenByAuthor(books []models.Book, author string) uint {
 	var count uint
 	for _, book := range books {
 		if book.Author == author {
@@ -3,7 +3,8 @@ package providers
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"testing"
 
@@ -34,16 +35,37 @@ func TestBooksProvider_GetBooks_OK(t *testing.T) {
 
 	provider := &booksProvider{
 		repo:   mockRepo,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	books := provider.GetBooks(context.Background())
+	books := provider.GetBooks(context.Background(), BooksFilter{})
 
 	assert.Len(t, books, 2)
 	assert.Equal(t, "Book 1", books[0].Name)
 	assert.Equal(t, "Author 1", books[0].Author)
 }
 
+func TestNewBooksProvider_WithBooksRepository(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20}},
+	}
+
+	provider, err := NewBooksProvider(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithBooksRepository(mockRepo))
+	assert.NoError(t, err)
+	books := provider.GetBooks(context.Background(), BooksFilter{})
+
+	assert.Equal(t, mockRepo.books, books)
+}
+
+func TestNewBooksProvider_InvalidDataQualityRules(t *testing.T) {
+	t.Setenv("DATA_QUALITY_CRITICAL_RULES", "zero_prize")
+
+	provider, err := NewBooksProvider(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithBooksRepository(&mockBooksRepository{}))
+
+	assert.ErrorIs(t, err, ErrUnknownQualityRule)
+	assert.Nil(t, provider)
+}
+
 func TestBooksProvider_GetBooks_Error(t *testing.T) {
 	mockRepo := &mockBooksRepository{
 		shouldError: true,
@@ -51,10 +73,10 @@ func TestBooksProvider_GetBooks_Error(t *testing.T) {
 
 	provider := &booksProvider{
 		repo:   mockRepo,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	books := provider.GetBooks(context.Background())
+	books := provider.GetBooks(context.Background(), BooksFilter{})
 
 	assert.Len(t, books, 0)
 }
@@ -70,16 +92,20 @@ func TestBooksProvider_GetMetrics_OK(t *testing.T) {
 
 	provider := &booksProvider{
 		repo:   mockRepo,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	metrics, err := provider.GetMetrics(context.Background(), "Alan Donovan")
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Author: "Alan Donovan"})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, metrics)
-	assert.Equal(t, uint(11000), metrics.MeanUnitsSold)
-	assert.Equal(t, "The Go Programming Language", metrics.CheapestBook)
-	assert.Equal(t, uint(1), metrics.BooksWrittenByAuthor)
+	assert.Equal(t, uint(11000), metrics.Value("mean_units_sold"))
+	assert.Equal(t, "The Go Programming Language", metrics.Value("cheapest_book"))
+	assert.Equal(t, uint(1), metrics.Value("books_written_by_author"))
+	assert.Equal(t, []string{"The Go Programming Language"}, metrics.Value("cheapest_books"))
+	assert.Equal(t, []string{"Clean Code"}, metrics.Value("most_expensive_books"))
+	assert.Equal(t, []string{"Clean Code"}, metrics.Value("best_sellers"))
+	assert.Equal(t, []string{"The Go Programming Language"}, metrics.Value("worst_sellers"))
 }
 
 func TestBooksProvider_GetMetrics_EmptyBooks(t *testing.T) {
@@ -89,16 +115,16 @@ func TestBooksProvider_GetMetrics_EmptyBooks(t *testing.T) {
 
 	provider := &booksProvider{
 		repo:   mockRepo,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	metrics, err := provider.GetMetrics(context.Background(), "Any Author")
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Author: "Any Author"})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, metrics)
-	assert.Equal(t, uint(0), metrics.MeanUnitsSold)
-	assert.Equal(t, "", metrics.CheapestBook)
-	assert.Equal(t, uint(0), metrics.BooksWrittenByAuthor)
+	assert.Equal(t, uint(0), metrics.Value("mean_units_sold"))
+	assert.Equal(t, "", metrics.Value("cheapest_book"))
+	assert.Equal(t, uint(0), metrics.Value("books_written_by_author"))
 }
 
 func TestBooksProvider_GetMetrics_NoAuthorMatch(t *testing.T) {
@@ -110,53 +136,259 @@ func TestBooksProvider_GetMetrics_NoAuthorMatch(t *testing.T) {
 
 	provider := &booksProvider{
 		repo:   mockRepo,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	metrics, err := provider.GetMetrics(context.Background(), "Nonexistent Author")
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Author: "Nonexistent Author"})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, metrics)
-	assert.Equal(t, uint(100), metrics.MeanUnitsSold)
-	assert.Equal(t, "Book 1", metrics.CheapestBook)
-	assert.Equal(t, uint(0), metrics.BooksWrittenByAuthor)
+	assert.Equal(t, uint(100), metrics.Value("mean_units_sold"))
+	assert.Equal(t, "Book 1", metrics.Value("cheapest_book"))
+	assert.Equal(t, uint(0), metrics.Value("books_written_by_author"))
+}
+
+func TestBooksProvider_GetMetrics_Stats(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 10},
+			{ID: 2, Name: "Book 2", UnitsSold: 101, Price: 20},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Stats: []string{StatMean, StatMedian}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(100), metrics.Value("mean_units_sold"))
+	assert.Equal(t, Distribution{StatMean: 100.5, StatMedian: 100.5}, metrics.Value("units_sold_stats"))
+	assert.Equal(t, Distribution{StatMean: 15, StatMedian: 15}, metrics.Value("price_stats"))
+}
+
+func TestBooksProvider_GetMetrics_UnknownStat(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Stats: []string{"p42"}})
+
+	assert.ErrorIs(t, err, ErrUnknownStat)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksProvider_GetMetrics_Filtered(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
+			{ID: 2, Name: "Go Concurrency", Author: "Alan Donovan", UnitsSold: 3000, Price: 25},
+			{ID: 3, Name: "Go in Action", Author: "William Kennedy", UnitsSold: 1000, Price: 20},
+			{ID: 4, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	maxPrice := uint(30)
+	filter := BooksFilter{AuthorContains: "donovan", MaxPrice: &maxPrice}
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Author: "Alan Donovan", Filter: filter})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, metrics.MatchedBooks)
+	assert.Equal(t, filter, metrics.Filter)
+	assert.Equal(t, uint(3000), metrics.Value("mean_units_sold"))
+	assert.Equal(t, "Go Concurrency", metrics.Value("cheapest_book"))
+	assert.Equal(t, uint(1), metrics.Value("books_written_by_author"))
+}
+
+func TestBooksProvider_GetMetrics_FilterMatchesNothing(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20}}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Filter: BooksFilter{Name: "missing"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, metrics.MatchedBooks)
+	assert.Equal(t, uint(0), metrics.Value("mean_units_sold"))
+}
+
+func TestBooksProvider_GetMetrics_InvalidFilter(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	minPrice, maxPrice := uint(50), uint(10)
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Filter: BooksFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}})
+
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.Nil(t, metrics)
 }
 
 func TestBooksProvider_CalculateMeanUnitsSold(t *testing.T) {
-	provider := &booksProvider{}
 	books := []models.Book{
 		{UnitsSold: 100},
 		{UnitsSold: 200},
 		{UnitsSold: 300},
 	}
 
-	mean := provider.meanUnitsSold(books)
+	mean := meanUnitsSold(books)
 	assert.Equal(t, uint(200), mean)
 }
 
+func TestBooksProvider_CalculateMeanUnitsSold_NoOverflow(t *testing.T) {
+	maxUint := ^uint(0)
+	books := []models.Book{
+		{UnitsSold: maxUint},
+		{UnitsSold: maxUint - 2},
+		{UnitsSold: maxUint - 1},
+	}
+
+	mean := meanUnitsSold(books)
+	assert.Equal(t, maxUint-1, mean)
+}
+
 func TestBooksProvider_cheapestBook(t *testing.T) {
-	provider := &booksProvider{}
 	books := []models.Book{
 		{Name: "Expensive", Price: 100},
 		{Name: "Cheap", Price: 20},
 		{Name: "Medium", Price: 50},
 	}
 
-	cheapest := provider.cheapestBook(books)
-	assert.Equal(t, "Cheap", cheapest.Name)
+	cheapest := cheapestBooks(books)
+	assert.Len(t, cheapest, 1)
+	assert.Equal(t, "Cheap", cheapest[0].Name)
+}
+
+func TestBooksProvider_cheapestBook_NoUnderflow(t *testing.T) {
+	books := []models.Book{
+		{Name: "Cheap", Price: 1},
+		{Name: "Expensive", Price: ^uint(0)},
+	}
+
+	cheapest := cheapestBooks(books)
+	assert.Len(t, cheapest, 1)
+	assert.Equal(t, "Cheap", cheapest[0].Name)
+}
+
+func TestBooksProvider_cheapestBook_Ties(t *testing.T) {
+	books := []models.Book{
+		{ID: 3, Name: "Cheap C", Price: 20},
+		{ID: 2, Name: "Expensive", Price: 100},
+		{ID: 1, Name: "Cheap A", Price: 20},
+		{ID: 4, Name: "Cheap D", Price: 20},
+	}
+
+	cheapest := cheapestBooks(books)
+	assert.Equal(t, []string{"Cheap A", "Cheap C", "Cheap D"}, bookNames(cheapest))
+}
+
+func TestBooksProvider_mostExpensiveBook(t *testing.T) {
+	books := []models.Book{
+		{ID: 2, Name: "Expensive B", Price: 100},
+		{ID: 3, Name: "Cheap", Price: 20},
+		{ID: 1, Name: "Expensive A", Price: 100},
+	}
+
+	expensive := mostExpensiveBooks(books)
+	assert.Equal(t, []string{"Expensive A", "Expensive B"}, bookNames(expensive))
+}
+
+func TestBooksProvider_bestAndWorstSeller(t *testing.T) {
+	books := []models.Book{
+		{ID: 1, Name: "Hit", UnitsSold: 900},
+		{ID: 2, Name: "Flop B", UnitsSold: 10},
+		{ID: 3, Name: "Average", UnitsSold: 400},
+		{ID: 4, Name: "Flop A", UnitsSold: 10},
+	}
+
+	assert.Equal(t, []string{"Hit"}, bookNames(bestSellers(books)))
+	assert.Equal(t, []string{"Flop B", "Flop A"}, bookNames(worstSellers(books)))
 }
 
 func TestBooksProvider_booksWrittenByAuthor(t *testing.T) {
-	provider := &booksProvider{}
 	books := []models.Book{
 		{Author: "Author A"},
 		{Author: "Author B"},
 		{Author: "Author A"},
 	}
 
-	count := provider.booksWrittenByAuthor(books, "Author A")
+	count := booksWrittenByAuthor(books, "Author A")
 	assert.Equal(t, uint(2), count)
 
-	count = provider.booksWrittenByAuthor(books, "Author C")
+	count = booksWrittenByAuthor(books, "Author C")
 	assert.Equal(t, uint(0), count)
 }
+
+func TestBooksProvider_GetMetricsBatch_OK(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
+			{ID: 2, Name: "Go Concurrency", Author: "Alan Donovan", UnitsSold: 3000, Price: 35},
+			{ID: 3, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetricsBatch(context.Background(), []string{"Alan Donovan", "Robert C. Martin", "Nobody", "Alan Donovan", ""})
+
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 3)
+	assert.Equal(t, AuthorMetrics{Found: true, BooksWrittenByAuthor: 2, MeanUnitsSold: 4000, CheapestBook: "Go Concurrency"}, metrics["Alan Donovan"])
+	assert.Equal(t, AuthorMetrics{Found: true, BooksWrittenByAuthor: 1, MeanUnitsSold: 15000, CheapestBook: "Clean Code"}, metrics["Robert C. Martin"])
+	assert.Equal(t, AuthorMetrics{Found: false}, metrics["Nobody"])
+}
+
+func TestBooksProvider_GetMetricsBatch_RepositoryError(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{shouldError: true},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetricsBatch(context.Background(), []string{"Alan Donovan"})
+
+	assert.ErrorIs(t, err, ErrCatalogUnavailable)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksProvider_GetMetricsBatch_NoAuthors(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetricsBatch(context.Background(), []string{""})
+
+	assert.ErrorIs(t, err, ErrNoAuthors)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksProvider_GetMetricsBatch_TooManyAuthors(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	authors := make([]string, MaxBatchAuthors+1)
+	for i := range authors {
+		authors[i] = fmt.Sprintf("Author %d", i)
+	}
+
+	metrics, err := provider.GetMetricsBatch(context.Background(), authors)
+
+	assert.ErrorIs(t, err, ErrTooManyAuthors)
+	assert.Nil(t, metrics)
+}
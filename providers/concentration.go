@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+
+	"educabot.com/bookshop/models"
+)
+
+// ParetoSalesShares are the cumulative shares of units sold reported as Pareto points
+var ParetoSalesShares = []float64{0.5, 0.8, 0.9}
+
+// ParetoPoint represents the smallest share of titles that accounts for a share of units sold
+type ParetoPoint struct {
+	SalesShare  float64 `json:"sales_share" example:"0.8"`
+	TitlesShare float64 `json:"titles_share" example:"0.2"`
+}
+
+// ConcentrationMetrics represents how concentrated sales are among titles and authors
+type ConcentrationMetrics struct {
+	// Gini is the Gini coefficient of units sold per title, from 0 (equal) to 1
+	Gini float64 `json:"gini" example:"0.25"`
+	// HHI is the Herfindahl-Hirschman index of units sold by author, from 0 to 10000
+	HHI float64 `json:"hhi" example:"3800"`
+	// TopDecileShare is the share of units sold by the best selling 10% of titles
+	TopDecileShare float64       `json:"top_decile_share" example:"0.4"`
+	ParetoPoints   []ParetoPoint `json:"pareto_points"`
+}
+
+func (p *booksProvider) GetConcentrationMetrics(ctx context.Context) (*ConcentrationMetrics, error) {
+	books, err := p.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return concentrationMetrics(books), nil
+}
+
+func concentrationMetrics(books []models.Book) *ConcentrationMetrics {
+	metrics := &ConcentrationMetrics{ParetoPoints: []ParetoPoint{}}
+
+	units := unitsSoldValues(books)
+	var total float64
+	for _, u := range units {
+		total += u
+	}
+	if total == 0 {
+		return metrics
+	}
+
+	slices.SortFunc(units, func(a, b float64) int { return cmp.Compare(b, a) })
+
+	metrics.Gini = gini(units, total)
+	metrics.HHI = herfindahlByAuthor(books, total)
+	metrics.TopDecileShare = topShare(units, total, 0.1)
+	for _, share := range ParetoSalesShares {
+		metrics.ParetoPoints = append(metrics.ParetoPoints, ParetoPoint{
+			SalesShare:  share,
+			TitlesShare: titlesShareFor(units, total, share),
+		})
+	}
+	return metrics
+}
+
+// gini expects values sorted in descending order
+func gini(desc []float64, total float64) float64 {
+	n := float64(len(desc))
+	var weighted float64
+	for i, value := range desc {
+		// rank in ascending order, starting at 1
+		weighted += (n - float64(i)) * value
+	}
+	return 2*weighted/(n*total) - (n+1)/n
+}
+
+func herfindahlByAuthor(books []models.Book, total float64) float64 {
+	byAuthor := make(map[string]float64)
+	for _, book := range books {
+		byAuthor[book.Author] += float64(book.UnitsSold)
+	}
+
+	var hhi float64
+	for _, units := range byAuthor {
+		share := units / total * 100
+		hhi += share * share
+	}
+	return hhi
+}
+
+// topShare expects values sorted in descending order and returns the share of
+// the total held by the top fraction of them, rounding the count up
+func topShare(desc []float64, total, fraction float64) float64 {
+	count := int(math.Ceil(float64(len(desc)) * fraction))
+	var sum float64
+	for _, value := range desc[:count] {
+		sum += value
+	}
+	return sum / total
+}
+
+// titlesShareFor expects values sorted in descending order
+func titlesShareFor(desc []float64, total, salesShare float64) float64 {
+	var cumulative float64
+	for i, value := range desc {
+		cumulative += value
+		if cumulative >= salesShare*total {
+			return float64(i+1) / float64(len(desc))
+		}
+	}
+	return 1
+}
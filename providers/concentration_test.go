@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksProvider_GetConcentrationMetrics_OK(t *testing.T) {
+	// Units sold 10, 20, 30 and 40 out of 100:
+	//   gini = 2*(1*10+2*20+3*30+4*40)/(4*100) - 5/4 = 0.25
+	//   hhi  = 50² + 20² + 30² = 3800
+	//   top 10% of 4 titles is 1 title holding 40 units
+	//   cumulative 40, 70, 90, 100 reaches 50% with 2 titles and 80% and 90% with 3
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Author: "Author A", UnitsSold: 10},
+			{ID: 2, Author: "Author B", UnitsSold: 20},
+			{ID: 3, Author: "Author C", UnitsSold: 30},
+			{ID: 4, Author: "Author A", UnitsSold: 40},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetConcentrationMetrics(context.Background())
+
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.25, metrics.Gini, 1e-9)
+	assert.InDelta(t, 3800, metrics.HHI, 1e-9)
+	assert.InDelta(t, 0.4, metrics.TopDecileShare, 1e-9)
+	assert.Equal(t, []ParetoPoint{
+		{SalesShare: 0.5, TitlesShare: 0.5},
+		{SalesShare: 0.8, TitlesShare: 0.75},
+		{SalesShare: 0.9, TitlesShare: 0.75},
+	}, metrics.ParetoPoints)
+}
+
+func TestBooksProvider_GetConcentrationMetrics_EqualSales(t *testing.T) {
+	// Five authors selling 100 units each: no inequality, hhi = 5 * 20² = 2000
+	books := make([]models.Book, 5)
+	for i := range books {
+		books[i] = models.Book{ID: uint(i + 1), Author: string(rune('A' + i)), UnitsSold: 100}
+	}
+
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: books},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetConcentrationMetrics(context.Background())
+
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, metrics.Gini, 1e-9)
+	assert.InDelta(t, 2000, metrics.HHI, 1e-9)
+	assert.InDelta(t, 0.2, metrics.TopDecileShare, 1e-9)
+	assert.Equal(t, 0.6, metrics.ParetoPoints[0].TitlesShare)
+	assert.Equal(t, 0.8, metrics.ParetoPoints[1].TitlesShare)
+	assert.Equal(t, 1.0, metrics.ParetoPoints[2].TitlesShare)
+}
+
+func TestBooksProvider_GetConcentrationMetrics_Monopoly(t *testing.T) {
+	// One title out of four holds every sale: gini = 2*(4*100)/(4*100) - 5/4 = 0.75
+	provider := &booksProvider{
+		repo: &mockBooksRepository{books: []models.Book{
+			{ID: 1, Author: "Author A", UnitsSold: 0},
+			{ID: 2, Author: "Author B", UnitsSold: 0},
+			{ID: 3, Author: "Author C", UnitsSold: 0},
+			{ID: 4, Author: "Author D", UnitsSold: 100},
+		}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetConcentrationMetrics(context.Background())
+
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.75, metrics.Gini, 1e-9)
+	assert.InDelta(t, 10000, metrics.HHI, 1e-9)
+	assert.InDelta(t, 1, metrics.TopDecileShare, 1e-9)
+	for _, point := range metrics.ParetoPoints {
+		assert.Equal(t, 0.25, point.TitlesShare)
+	}
+}
+
+func TestBooksProvider_GetConcentrationMetrics_NoSales(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{{ID: 1}}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetConcentrationMetrics(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ConcentrationMetrics{ParetoPoints: []ParetoPoint{}}, metrics)
+}
+
+func TestBooksProvider_GetConcentrationMetrics_RepositoryError(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{shouldError: true},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetConcentrationMetrics(context.Background())
+
+	assert.ErrorIs(t, err, ErrCatalogUnavailable)
+	assert.Nil(t, metrics)
+}
@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"errors"
+	"strings"
+
+	"educabot.com/bookshop/models"
+)
+
+var ErrInvalidFilter = errors.New("invalid books filter")
+
+// BooksFilter represents the criteria used to narrow down the catalog. Empty
+// fields match every book and text fields are matched case-insensitively.
+type BooksFilter struct {
+	Name           string `json:"name,omitempty" example:"go"`
+	AuthorContains string `json:"author_contains,omitempty" example:"donovan"`
+	MinPrice       *uint  `json:"min_price,omitempty" example:"10"`
+	MaxPrice       *uint  `json:"max_price,omitempty" example:"30"`
+	MinUnitsSold   *uint  `json:"min_units_sold,omitempty" example:"1000"`
+	MaxUnitsSold   *uint  `json:"max_units_sold,omitempty" example:"50000"`
+}
+
+// Validate rejects ranges whose lower bound is above the upper bound
+func (f BooksFilter) Validate() error {
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return ErrInvalidFilter
+	}
+	if f.MinUnitsSold != nil && f.MaxUnitsSold != nil && *f.MinUnitsSold > *f.MaxUnitsSold {
+		return ErrInvalidFilter
+	}
+	return nil
+}
+
+// Match reports whether book satisfies every criteria of the filter
+func (f BooksFilter) Match(book models.Book) bool {
+	if f.Name != "" && !containsFold(book.Name, f.Name) {
+		return false
+	}
+	if f.AuthorContains != "" && !containsFold(book.Author, f.AuthorContains) {
+		return false
+	}
+	if f.MinPrice != nil && book.Price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && book.Price > *f.MaxPrice {
+		return false
+	}
+	if f.MinUnitsSold != nil && book.UnitsSold < *f.MinUnitsSold {
+		return false
+	}
+	if f.MaxUnitsSold != nil && book.UnitsSold > *f.MaxUnitsSold {
+		return false
+	}
+	return true
+}
+
+// Apply returns the books matching the filter, keeping their order
+func (f BooksFilter) Apply(books []models.Book) []models.Book {
+	if f == (BooksFilter{}) {
+		return books
+	}
+	matched := make([]models.Book, 0, len(books))
+	for _, book := range books {
+		if f.Match(book) {
+			matched = append(matched, book)
+		}
+	}
+	return matched
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
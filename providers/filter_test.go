@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestBooksFilter_Apply(t *testing.T) {
+	books := []models.Book{
+		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
+		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		{ID: 3, Name: "Go in Action", Author: "William Kennedy", UnitsSold: 2000, Price: 25},
+	}
+
+	tests := []struct {
+		name   string
+		filter BooksFilter
+		want   []uint
+	}{
+		{"empty filter", BooksFilter{}, []uint{1, 2, 3}},
+		{"name substring", BooksFilter{Name: "GO "}, []uint{1, 3}},
+		{"author substring", BooksFilter{AuthorContains: "martin"}, []uint{2}},
+		{"price range", BooksFilter{MinPrice: uintPtr(30), MaxPrice: uintPtr(45)}, []uint{1}},
+		{"units sold range", BooksFilter{MinUnitsSold: uintPtr(2000), MaxUnitsSold: uintPtr(5000)}, []uint{1, 3}},
+		{"combined", BooksFilter{Name: "go", MaxPrice: uintPtr(30)}, []uint{3}},
+		{"no match", BooksFilter{AuthorContains: "nobody"}, []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []uint{}
+			for _, book := range tt.filter.Apply(books) {
+				ids = append(ids, book.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestBooksFilter_Validate(t *testing.T) {
+	assert.NoError(t, BooksFilter{}.Validate())
+	assert.NoError(t, BooksFilter{MinPrice: uintPtr(10), MaxPrice: uintPtr(10)}.Validate())
+	assert.ErrorIs(t, BooksFilter{MinPrice: uintPtr(20), MaxPrice: uintPtr(10)}.Validate(), ErrInvalidFilter)
+	assert.ErrorIs(t, BooksFilter{MinUnitsSold: uintPtr(20), MaxUnitsSold: uintPtr(10)}.Validate(), ErrInvalidFilter)
+}
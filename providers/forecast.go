@@ -0,0 +1,240 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"educabot.com/bookshop/models"
+)
+
+// Forecasting methods
+const (
+	ForecastLinear = "linear"
+	ForecastHolt   = "holt"
+)
+
+const (
+	// ForecastConfidence is the confidence level of the forecast intervals
+	ForecastConfidence     = 0.95
+	forecastZ              = 1.96
+	minForecastPoints      = 3
+	maxForecastHorizon     = 365 * 24 * time.Hour
+	defaultForecastHorizon = 30 * 24 * time.Hour
+	holtAlpha              = 0.5
+	holtBeta               = 0.3
+)
+
+var (
+	ErrInvalidForecast = errors.New("invalid forecast parameters")
+	ErrUnitsOverflow   = errors.New("units sold overflow")
+)
+
+// ForecastOptions represents the parameters of a forecast request. Zero values
+// default to a linear forecast 30 days after the last observation.
+type ForecastOptions struct {
+	Method  string
+	Horizon time.Duration
+}
+
+// Forecast represents the projected cumulative units sold at a point in time.
+// Catalog versions whose units sold do not fit in an unsigned integer are
+// left out of the series and listed in OverflowedVersions.
+type Forecast struct {
+	BookID       uint      `json:"book_id,omitempty" example:"1"`
+	Author       string    `json:"author,omitempty" example:"Alan Donovan"`
+	Method       string    `json:"method" example:"linear"`
+	Horizon      string    `json:"horizon" example:"720h0m0s"`
+	At           time.Time `json:"at" example:"2025-02-09T12:00:00Z"`
+	Observations int       `json:"observations" example:"12"`
+	LastObserved uint      `json:"last_observed" example:"5000"`
+	UnitsSold    float64   `json:"units_sold" example:"6200"`
+	Lower        float64   `json:"lower" example:"5800"`
+	Upper        float64   `json:"upper" example:"6600"`
+	Confidence   float64   `json:"confidence" example:"0.95"`
+
+	OverflowedVersions []int `json:"overflowed_versions,omitempty" example:"7"`
+}
+
+// observation represents a cumulative units sold value, at days since the first one
+type observation struct {
+	day   float64
+	units float64
+}
+
+func (p *booksProvider) GetBookForecast(id uint, opts ForecastOptions) (*Forecast, error) {
+	return p.forecast(opts, func(books []models.Book) (uint, bool, error) {
+		for _, book := range books {
+			if book.ID == id {
+				return book.UnitsSold, true, nil
+			}
+		}
+		return 0, false, nil
+	}, Forecast{BookID: id})
+}
+
+func (p *booksProvider) GetAuthorForecast(author string, opts ForecastOptions) (*Forecast, error) {
+	return p.forecast(opts, func(books []models.Book) (uint, bool, error) {
+		var units, carry uint
+		found := false
+		for _, book := range books {
+			if book.Author == author {
+				units, carry = bits.Add(units, book.UnitsSold, 0)
+				if carry != 0 {
+					return 0, true, ErrUnitsOverflow
+				}
+				found = true
+			}
+		}
+		return units, found, nil
+	}, Forecast{Author: author})
+}
+
+// forecast projects the series obtained by applying units to every recorded
+// catalog version. Each version is observed when it was fetched and, as
+// unchanged fetches are compacted into it, when it was last seen.
+func (p *booksProvider) forecast(opts ForecastOptions, units func([]models.Book) (uint, bool, error), forecast Forecast) (*Forecast, error) {
+	if opts.Method == "" {
+		opts.Method = ForecastLinear
+	}
+	if opts.Horizon == 0 {
+		opts.Horizon = defaultForecastHorizon
+	}
+	if opts.Method != ForecastLinear && opts.Method != ForecastHolt {
+		return nil, fmt.Errorf("%w: unknown method %q", ErrInvalidForecast, opts.Method)
+	}
+	if opts.Horizon < 0 || opts.Horizon > maxForecastHorizon {
+		return nil, fmt.Errorf("%w: horizon must be between 0 and %s", ErrInvalidForecast, maxForecastHorizon)
+	}
+
+	var versions []models.CatalogVersion
+	if p.history != nil {
+		versions = p.history.Versions()
+	}
+
+	var series []observation
+	var first, last time.Time
+	found := false
+	for _, version := range versions {
+		value, ok, err := units(version.Books)
+		if !ok {
+			continue
+		}
+		found = true
+		if err != nil {
+			forecast.OverflowedVersions = append(forecast.OverflowedVersions, version.Version)
+			continue
+		}
+		if len(series) == 0 {
+			first = version.FetchedAt
+		}
+		last = version.LastSeenAt
+		forecast.LastObserved = value
+		series = append(series, observation{day: days(version.FetchedAt.Sub(first)), units: float64(value)})
+		if version.LastSeenAt.After(version.FetchedAt) {
+			series = append(series, observation{day: days(version.LastSeenAt.Sub(first)), units: float64(value)})
+		}
+	}
+	if len(forecast.OverflowedVersions) > 0 {
+		p.logger.Warn("Left catalog versions out of the forecast", "error", ErrUnitsOverflow, "versions", forecast.OverflowedVersions)
+	}
+	if !found {
+		return nil, ErrBookNotFound
+	}
+	if len(series) < minForecastPoints {
+		return nil, ErrNotEnoughHistory
+	}
+
+	at := last.Add(opts.Horizon)
+	target := days(at.Sub(first))
+	var value, margin float64
+	if opts.Method == ForecastHolt {
+		value, margin = holtForecast(series, target)
+	} else {
+		value, margin = linearForecast(series, target)
+	}
+
+	forecast.Method = opts.Method
+	forecast.Horizon = opts.Horizon.String()
+	forecast.At = at
+	forecast.Observations = len(series)
+	forecast.UnitsSold = value
+	// units sold are cumulative, they cannot go below the last observation
+	forecast.Lower = math.Max(value-margin, float64(forecast.LastObserved))
+	forecast.Upper = math.Max(value+margin, forecast.Lower)
+	forecast.Confidence = ForecastConfidence
+	return &forecast, nil
+}
+
+// linearForecast fits an ordinary least squares line and returns the value at
+// target and the half width of its prediction interval
+func linearForecast(series []observation, target float64) (float64, float64) {
+	n := float64(len(series))
+	var meanDay, meanUnits float64
+	for _, o := range series {
+		meanDay += o.day / n
+		meanUnits += o.units / n
+	}
+
+	var sxx, sxy float64
+	for _, o := range series {
+		sxx += (o.day - meanDay) * (o.day - meanDay)
+		sxy += (o.day - meanDay) * (o.units - meanUnits)
+	}
+	var slope float64
+	if sxx > 0 {
+		slope = sxy / sxx
+	}
+	intercept := meanUnits - slope*meanDay
+
+	var sse float64
+	for _, o := range series {
+		residual := o.units - (intercept + slope*o.day)
+		sse += residual * residual
+	}
+	stderr := math.Sqrt(sse / (n - 2))
+
+	leverage := 1 + 1/n
+	if sxx > 0 {
+		leverage += (target - meanDay) * (target - meanDay) / sxx
+	}
+	return intercept + slope*target, forecastZ * stderr * math.Sqrt(leverage)
+}
+
+// holtForecast applies Holt's linear exponential smoothing. Observations are
+// not evenly spaced, so the trend is kept per day and each update is scaled by
+// the time elapsed since the previous observation. The interval grows with
+// the square root of the number of average steps to the target.
+func holtForecast(series []observation, target float64) (float64, float64) {
+	level := series[0].units
+	trend := (series[1].units - series[0].units) / math.Max(series[1].day-series[0].day, 1e-9)
+
+	var sse float64
+	for i := 1; i < len(series); i++ {
+		step := series[i].day - series[i-1].day
+		predicted := level + trend*step
+		residual := series[i].units - predicted
+		sse += residual * residual
+
+		previous := level
+		level = holtAlpha*series[i].units + (1-holtAlpha)*predicted
+		if step > 0 {
+			trend = holtBeta*(level-previous)/step + (1-holtBeta)*trend
+		}
+	}
+
+	lastDay := series[len(series)-1].day
+	meanStep := lastDay / float64(len(series)-1)
+	steps := 1.0
+	if meanStep > 0 {
+		steps = math.Max((target-lastDay)/meanStep, 1)
+	}
+	rmse := math.Sqrt(sse / float64(len(series)-1))
+	return level + trend*(target-lastDay), forecastZ * rmse * math.Sqrt(steps)
+}
+
+func days(d time.Duration) float64 {
+	return d.Hours() / 24
+}
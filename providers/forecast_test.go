@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticSeries returns daily cumulative units sold following f plus
+// gaussian noise of the given standard deviation
+func syntheticSeries(days int, f func(day float64) float64, noise float64, seed int64) []observation {
+	rng := rand.New(rand.NewSource(seed))
+	series := make([]observation, days)
+	for i := range series {
+		series[i] = observation{day: float64(i), units: f(float64(i)) + rng.NormFloat64()*noise}
+	}
+	return series
+}
+
+// backtest forecasts the last holdout points from the ones before them and
+// returns the mean absolute percentage error and the share of actual values
+// inside the interval
+func backtest(series []observation, holdout int, forecast func([]observation, float64) (float64, float64)) (float64, float64) {
+	train := series[:len(series)-holdout]
+	var mape float64
+	var covered int
+	for _, actual := range series[len(series)-holdout:] {
+		predicted, margin := forecast(train, actual.day)
+		mape += math.Abs(predicted-actual.units) / actual.units / float64(holdout)
+		if math.Abs(predicted-actual.units) <= margin {
+			covered++
+		}
+	}
+	return mape, float64(covered) / float64(holdout)
+}
+
+func TestLinearForecast_ExactLine(t *testing.T) {
+	series := syntheticSeries(10, func(day float64) float64 { return 1000 + 50*day }, 0, 1)
+
+	value, margin := linearForecast(series, 20)
+
+	assert.InDelta(t, 2000, value, 1e-6)
+	assert.InDelta(t, 0, margin, 1e-6)
+}
+
+func TestLinearForecast_Backtest(t *testing.T) {
+	series := syntheticSeries(90, func(day float64) float64 { return 5000 + 120*day }, 40, 7)
+
+	mape, coverage := backtest(series, 30, linearForecast)
+
+	assert.Less(t, mape, 0.02)
+	assert.GreaterOrEqual(t, coverage, 0.9)
+}
+
+func TestHoltForecast_Backtest(t *testing.T) {
+	series := syntheticSeries(90, func(day float64) float64 { return 5000 + 120*day }, 40, 11)
+
+	mape, coverage := backtest(series, 30, holtForecast)
+
+	assert.Less(t, mape, 0.03)
+	assert.GreaterOrEqual(t, coverage, 0.8)
+}
+
+func TestHoltForecast_FollowsAcceleration(t *testing.T) {
+	// sales speed up halfway: the smoothed trend catches up, the regression line does not
+	f := func(day float64) float64 {
+		if day < 45 {
+			return 1000 + 10*day
+		}
+		return 1450 + 200*(day-45)
+	}
+	series := syntheticSeries(90, f, 5, 3)
+
+	holtMAPE, _ := backtest(series, 10, holtForecast)
+	linearMAPE, _ := backtest(series, 10, linearForecast)
+
+	assert.Less(t, holtMAPE, linearMAPE)
+	assert.Less(t, holtMAPE, 0.02)
+}
+
+func TestHoltForecast_UnevenSpacing(t *testing.T) {
+	series := []observation{{0, 100}, {1, 110}, {3, 130}, {4, 140}, {8, 180}}
+
+	value, _ := holtForecast(series, 10)
+
+	assert.InDelta(t, 200, value, 1e-6)
+}
+
+func TestBooksProvider_GetBookForecast_OK(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:       {{ID: 1, UnitsSold: 1000}, {ID: 2, Author: "Other", UnitsSold: 7}},
+		day:     {{ID: 1, UnitsSold: 1100}, {ID: 2, Author: "Other", UnitsSold: 8}},
+		2 * day: {{ID: 1, UnitsSold: 1200}, {ID: 2, Author: "Other", UnitsSold: 9}},
+		3 * day: {{ID: 1, UnitsSold: 1300}, {ID: 2, Author: "Other", UnitsSold: 10}},
+	})
+
+	forecast, err := provider.GetBookForecast(1, ForecastOptions{Horizon: 10 * day})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), forecast.BookID)
+	assert.Equal(t, ForecastLinear, forecast.Method)
+	assert.Equal(t, "240h0m0s", forecast.Horizon)
+	assert.Equal(t, seriesStart.Add(13*day), forecast.At)
+	assert.Equal(t, 4, forecast.Observations)
+	assert.Equal(t, uint(1300), forecast.LastObserved)
+	assert.InDelta(t, 2300, forecast.UnitsSold, 1e-6)
+	assert.InDelta(t, 2300, forecast.Lower, 1e-6)
+	assert.InDelta(t, 2300, forecast.Upper, 1e-6)
+	assert.Equal(t, ForecastConfidence, forecast.Confidence)
+}
+
+func TestBooksProvider_GetAuthorForecast_OK(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:       {{ID: 1, Author: "A", UnitsSold: 10}, {ID: 2, Author: "A", UnitsSold: 20}},
+		day:     {{ID: 1, Author: "A", UnitsSold: 15}, {ID: 2, Author: "A", UnitsSold: 25}},
+		2 * day: {{ID: 1, Author: "A", UnitsSold: 20}, {ID: 2, Author: "A", UnitsSold: 30}},
+	})
+
+	forecast, err := provider.GetAuthorForecast("A", ForecastOptions{Method: ForecastHolt, Horizon: day})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "A", forecast.Author)
+	assert.Equal(t, uint(50), forecast.LastObserved)
+	assert.InDelta(t, 60, forecast.UnitsSold, 1e-6)
+}
+
+func TestBooksProvider_GetBookForecast_UsesLastSeen(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:       {{ID: 1, UnitsSold: 100}},
+		day:     {{ID: 1, UnitsSold: 200}},
+		2 * day: {{ID: 1, UnitsSold: 300}},
+		// compacted into the previous version, sales stalled since
+		10 * day: {{ID: 1, UnitsSold: 300}},
+	})
+
+	forecast, err := provider.GetBookForecast(1, ForecastOptions{Horizon: day})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, forecast.Observations)
+	assert.Equal(t, seriesStart.Add(11*day), forecast.At)
+	assert.Equal(t, uint(300), forecast.LastObserved)
+	assert.InDelta(t, 333.07, forecast.UnitsSold, 0.01)
+}
+
+func TestBooksProvider_GetAuthorForecast_Overflow(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:       {{ID: 1, Author: "A", UnitsSold: 10}, {ID: 2, Author: "A", UnitsSold: 20}},
+		day:     {{ID: 1, Author: "A", UnitsSold: math.MaxUint}, {ID: 2, Author: "A", UnitsSold: 25}},
+		2 * day: {{ID: 1, Author: "A", UnitsSold: 20}, {ID: 2, Author: "A", UnitsSold: 30}},
+		3 * day: {{ID: 1, Author: "A", UnitsSold: 25}, {ID: 2, Author: "A", UnitsSold: 35}},
+	})
+	provider.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	forecast, err := provider.GetAuthorForecast("A", ForecastOptions{Horizon: day})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, forecast.OverflowedVersions)
+	assert.Equal(t, 3, forecast.Observations)
+	assert.Equal(t, uint(60), forecast.LastObserved)
+}
+
+func TestBooksProvider_GetBookForecast_Errors(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:         {{ID: 1, UnitsSold: 1}},
+		time.Hour: {{ID: 1, UnitsSold: 2}},
+	})
+
+	_, err := provider.GetBookForecast(1, ForecastOptions{})
+	assert.ErrorIs(t, err, ErrNotEnoughHistory)
+
+	_, err = provider.GetBookForecast(2, ForecastOptions{})
+	assert.ErrorIs(t, err, ErrBookNotFound)
+
+	_, err = provider.GetBookForecast(1, ForecastOptions{Method: "arima"})
+	assert.ErrorIs(t, err, ErrInvalidForecast)
+
+	_, err = provider.GetBookForecast(1, ForecastOptions{Horizon: 2 * maxForecastHorizon})
+	assert.ErrorIs(t, err, ErrInvalidForecast)
+
+	_, err = (&booksProvider{}).GetAuthorForecast("A", ForecastOptions{})
+	assert.ErrorIs(t, err, ErrBookNotFound)
+}
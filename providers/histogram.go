@@ -0,0 +1,205 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"educabot.com/bookshop/models"
+)
+
+// Fields a histogram can be built over
+const (
+	HistogramFieldPrice     = "price"
+	HistogramFieldUnitsSold = "units_sold"
+)
+
+// MaxHistogramBuckets caps the number of buckets a single histogram may produce
+const MaxHistogramBuckets = 1000
+
+var ErrInvalidHistogram = errors.New("invalid histogram parameters")
+
+// HistogramOptions represents the parameters of a histogram request. Exactly
+// one of Width, Edges or Quantiles must be set.
+type HistogramOptions struct {
+	Field     string
+	Width     uint
+	Edges     []float64
+	Quantiles int
+	Filter    BooksFilter
+}
+
+// HistogramBucket represents the books whose value falls in [Lower, Upper).
+// The last bucket of a histogram also includes its upper bound.
+type HistogramBucket struct {
+	Lower   float64 `json:"lower" example:"20"`
+	Upper   float64 `json:"upper" example:"30"`
+	Count   int     `json:"count" example:"2"`
+	BookIDs []uint  `json:"book_ids"`
+}
+
+// Histogram represents the distribution of a book field over buckets
+type Histogram struct {
+	Field   string            `json:"field" example:"price"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+func (p *booksProvider) GetHistogram(ctx context.Context, opts HistogramOptions) (*Histogram, error) {
+	if err := opts.Filter.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateHistogramOptions(opts); err != nil {
+		return nil, err
+	}
+
+	books, err := p.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return histogram(opts.Filter.Apply(books), opts)
+}
+
+func validateHistogramOptions(opts HistogramOptions) error {
+	if opts.Field != HistogramFieldPrice && opts.Field != HistogramFieldUnitsSold {
+		return fmt.Errorf("%w: unknown field %q", ErrInvalidHistogram, opts.Field)
+	}
+
+	modes := 0
+	if opts.Width > 0 {
+		modes++
+	}
+	if len(opts.Edges) > 0 {
+		modes++
+	}
+	if opts.Quantiles > 0 {
+		modes++
+	}
+	if modes != 1 {
+		return fmt.Errorf("%w: exactly one of width, edges or quantiles is required", ErrInvalidHistogram)
+	}
+
+	if len(opts.Edges) > 0 {
+		if len(opts.Edges) < 2 || len(opts.Edges) > MaxHistogramBuckets+1 {
+			return fmt.Errorf("%w: between 2 and %d edges are required", ErrInvalidHistogram, MaxHistogramBuckets+1)
+		}
+		for _, edge := range opts.Edges {
+			if math.IsNaN(edge) || math.IsInf(edge, 0) {
+				return fmt.Errorf("%w: edges must be finite numbers", ErrInvalidHistogram)
+			}
+		}
+		for i := 1; i < len(opts.Edges); i++ {
+			if opts.Edges[i] <= opts.Edges[i-1] {
+				return fmt.Errorf("%w: edges must be strictly increasing", ErrInvalidHistogram)
+			}
+		}
+	}
+	if opts.Quantiles > MaxHistogramBuckets {
+		return fmt.Errorf("%w: at most %d quantiles are allowed", ErrInvalidHistogram, MaxHistogramBuckets)
+	}
+	return nil
+}
+
+func histogram(books []models.Book, opts HistogramOptions) (*Histogram, error) {
+	value := bookPrice
+	if opts.Field == HistogramFieldUnitsSold {
+		value = bookUnitsSold
+	}
+
+	var edges []float64
+	switch {
+	case len(opts.Edges) > 0:
+		edges = opts.Edges
+	case len(books) == 0:
+		return &Histogram{Field: opts.Field, Buckets: []HistogramBucket{}}, nil
+	case opts.Width > 0:
+		var err error
+		if edges, err = widthEdges(books, value, opts.Width); err != nil {
+			return nil, err
+		}
+	default:
+		edges = quantileEdges(books, value, opts.Quantiles)
+	}
+
+	buckets := make([]HistogramBucket, len(edges)-1)
+	for i := range buckets {
+		buckets[i] = HistogramBucket{Lower: edges[i], Upper: edges[i+1], BookIDs: []uint{}}
+	}
+	for _, book := range books {
+		i := bucketIndex(edges, float64(value(book)))
+		if i < 0 {
+			continue
+		}
+		buckets[i].Count++
+		buckets[i].BookIDs = append(buckets[i].BookIDs, book.ID)
+	}
+	for i := range buckets {
+		slices.Sort(buckets[i].BookIDs)
+	}
+
+	return &Histogram{Field: opts.Field, Buckets: buckets}, nil
+}
+
+// widthEdges aligns the first edge to a multiple of width below the minimum value
+func widthEdges(books []models.Book, value func(models.Book) uint, width uint) ([]float64, error) {
+	byValue := func(a, b models.Book) int { return cmp.Compare(value(a), value(b)) }
+	low := value(slices.MinFunc(books, byValue))
+	high := value(slices.MaxFunc(books, byValue))
+
+	start := low - low%width
+	count := (high-start)/width + 1
+	if count > MaxHistogramBuckets {
+		return nil, fmt.Errorf("%w: width produces more than %d buckets", ErrInvalidHistogram, MaxHistogramBuckets)
+	}
+
+	edges := make([]float64, count+1)
+	for i := range edges {
+		edges[i] = float64(start) + float64(i)*float64(width)
+	}
+	return edges, nil
+}
+
+// quantileEdges splits the values in buckets holding roughly the same number of
+// books. Repeated values can make edges collapse, in which case fewer buckets
+// are returned.
+func quantileEdges(books []models.Book, value func(models.Book) uint, quantiles int) []float64 {
+	values := make([]float64, len(books))
+	for i, book := range books {
+		values[i] = float64(value(book))
+	}
+	slices.Sort(values)
+
+	edges := make([]float64, 0, quantiles+1)
+	for i := 0; i <= quantiles; i++ {
+		edge := percentile(values, float64(i)/float64(quantiles))
+		if len(edges) > 0 && edge <= edges[len(edges)-1] {
+			continue
+		}
+		edges = append(edges, edge)
+	}
+	if len(edges) == 1 {
+		edges = append(edges, edges[0])
+	}
+	return edges
+}
+
+// bucketIndex returns the bucket v falls into, or -1 when it is out of range
+func bucketIndex(edges []float64, v float64) int {
+	last := len(edges) - 1
+	if v < edges[0] || v > edges[last] || math.IsNaN(v) {
+		return -1
+	}
+	if v == edges[last] {
+		return last - 1
+	}
+	// the first edge strictly greater than v closes v's bucket
+	i, _ := slices.BinarySearchFunc(edges, v, func(edge, target float64) int {
+		if edge <= target {
+			return -1
+		}
+		return 1
+	})
+	return i - 1
+}
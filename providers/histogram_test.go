@@ -0,0 +1,174 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var histogramBooks = []models.Book{
+	{ID: 1, Name: "Book 1", Author: "Author A", UnitsSold: 100, Price: 12},
+	{ID: 2, Name: "Book 2", Author: "Author A", UnitsSold: 200, Price: 18},
+	{ID: 3, Name: "Book 3", Author: "Author B", UnitsSold: 300, Price: 25},
+	{ID: 4, Name: "Book 4", Author: "Author B", UnitsSold: 400, Price: 30},
+	{ID: 5, Name: "Book 5", Author: "Author C", UnitsSold: 500, Price: 47},
+}
+
+func TestBooksProvider_GetHistogram_Width(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: histogramBooks},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldPrice, Width: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, HistogramFieldPrice, histogram.Field)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 10, Upper: 20, Count: 2, BookIDs: []uint{1, 2}},
+		{Lower: 20, Upper: 30, Count: 1, BookIDs: []uint{3}},
+		{Lower: 30, Upper: 40, Count: 1, BookIDs: []uint{4}},
+		{Lower: 40, Upper: 50, Count: 1, BookIDs: []uint{5}},
+	}, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_Edges(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: histogramBooks},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{
+		Field: HistogramFieldUnitsSold,
+		Edges: []float64{150, 300, 400},
+	})
+
+	assert.NoError(t, err)
+	// 100 and 500 fall outside the edges, 400 is included by the last bucket
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 150, Upper: 300, Count: 1, BookIDs: []uint{2}},
+		{Lower: 300, Upper: 400, Count: 2, BookIDs: []uint{3, 4}},
+	}, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_Quantiles(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: histogramBooks},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldUnitsSold, Quantiles: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 100, Upper: 300, Count: 2, BookIDs: []uint{1, 2}},
+		{Lower: 300, Upper: 500, Count: 3, BookIDs: []uint{3, 4, 5}},
+	}, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_QuantilesCollapse(t *testing.T) {
+	provider := &booksProvider{
+		repo: &mockBooksRepository{books: []models.Book{
+			{ID: 1, Price: 10},
+			{ID: 2, Price: 10},
+		}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldPrice, Quantiles: 4})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 10, Upper: 10, Count: 2, BookIDs: []uint{1, 2}},
+	}, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_Filtered(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: histogramBooks},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{
+		Field:  HistogramFieldPrice,
+		Width:  50,
+		Filter: BooksFilter{AuthorContains: "author b"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 0, Upper: 50, Count: 2, BookIDs: []uint{3, 4}},
+	}, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_RepositoryError(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{shouldError: true},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldPrice, Width: 10})
+
+	assert.ErrorIs(t, err, ErrCatalogUnavailable)
+	assert.Nil(t, histogram)
+}
+
+func TestBooksProvider_GetHistogram_EmptyBooks(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldPrice, Width: 10})
+
+	assert.NoError(t, err)
+	assert.Empty(t, histogram.Buckets)
+}
+
+func TestBooksProvider_GetHistogram_InvalidOptions(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: histogramBooks},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	tests := []struct {
+		name string
+		opts HistogramOptions
+	}{
+		{"unknown field", HistogramOptions{Field: "rating", Width: 10}},
+		{"no mode", HistogramOptions{Field: HistogramFieldPrice}},
+		{"several modes", HistogramOptions{Field: HistogramFieldPrice, Width: 10, Quantiles: 4}},
+		{"single edge", HistogramOptions{Field: HistogramFieldPrice, Edges: []float64{10}}},
+		{"unsorted edges", HistogramOptions{Field: HistogramFieldPrice, Edges: []float64{10, 5}}},
+		{"NaN edge", HistogramOptions{Field: HistogramFieldPrice, Edges: []float64{math.NaN(), 5}}},
+		{"infinite edge", HistogramOptions{Field: HistogramFieldPrice, Edges: []float64{0, math.Inf(1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histogram, err := provider.GetHistogram(context.Background(), tt.opts)
+			assert.ErrorIs(t, err, ErrInvalidHistogram)
+			assert.Nil(t, histogram)
+		})
+	}
+}
+
+func TestBooksProvider_GetHistogram_TooManyBuckets(t *testing.T) {
+	provider := &booksProvider{
+		repo: &mockBooksRepository{books: []models.Book{
+			{ID: 1, UnitsSold: 0},
+			{ID: 2, UnitsSold: MaxHistogramBuckets * 10},
+		}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	histogram, err := provider.GetHistogram(context.Background(), HistogramOptions{Field: HistogramFieldUnitsSold, Width: 1})
+
+	assert.ErrorIs(t, err, ErrInvalidHistogram)
+	assert.Nil(t, histogram)
+}
@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"errors"
+
+	"educabot.com/bookshop/models"
+)
+
+var ErrBookNotFound = errors.New("book not found")
+
+// BookHistory represents the recorded changes of a single book
+type BookHistory struct {
+	ID      uint                `json:"id" example:"1"`
+	Current *models.Book        `json:"current,omitempty"`
+	Changes []models.BookChange `json:"changes"`
+}
+
+func (p *booksProvider) GetBookHistory(id uint) (*BookHistory, error) {
+	if p.history == nil {
+		return nil, ErrBookNotFound
+	}
+
+	changes := p.history.BookChanges(id)
+	if len(changes) == 0 {
+		return nil, ErrBookNotFound
+	}
+
+	return &BookHistory{
+		ID:      id,
+		Current: changes[len(changes)-1].After,
+		Changes: changes,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksProvider_GetBookHistory_OK(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20}},
+	}
+
+	provider := &booksProvider{
+		repo:    mockRepo,
+		history: repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{}),
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	provider.GetBooks(context.Background(), BooksFilter{})
+	mockRepo.books = []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 180, Price: 18}}
+	provider.GetBooks(context.Background(), BooksFilter{Name: "does not matter"})
+
+	history, err := provider.GetBookHistory(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), history.ID)
+	assert.Equal(t, uint(18), history.Current.Price)
+	assert.Len(t, history.Changes, 2)
+	assert.Equal(t, models.BookUpdated, history.Changes[1].Type)
+	assert.Equal(t, uint(100), history.Changes[1].Before.UnitsSold)
+	assert.Equal(t, uint(180), history.Changes[1].After.UnitsSold)
+}
+
+func TestBooksProvider_GetBookHistory_Removed(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{{ID: 1, Name: "Book 1"}},
+	}
+
+	provider := &booksProvider{
+		repo:    mockRepo,
+		history: repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{}),
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	provider.GetBooks(context.Background(), BooksFilter{})
+	mockRepo.books = []models.Book{}
+	provider.GetBooks(context.Background(), BooksFilter{})
+
+	history, err := provider.GetBookHistory(1)
+
+	assert.NoError(t, err)
+	assert.Nil(t, history.Current)
+	assert.Equal(t, models.BookRemoved, history.Changes[1].Type)
+}
+
+func TestBooksProvider_GetBookHistory_FetchErrorIsNotRecorded(t *testing.T) {
+	provider := &booksProvider{
+		repo:    &mockBooksRepository{shouldError: true},
+		history: repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{}),
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	provider.GetBooks(context.Background(), BooksFilter{})
+
+	assert.Empty(t, provider.history.Versions())
+}
+
+func TestBooksProvider_GetBookHistory_NotFound(t *testing.T) {
+	provider := &booksProvider{
+		repo:    &mockBooksRepository{books: []models.Book{{ID: 1}}},
+		history: repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{}),
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	provider.GetBooks(context.Background(), BooksFilter{})
+
+	history, err := provider.GetBookHistory(2)
+
+	assert.ErrorIs(t, err, ErrBookNotFound)
+	assert.Nil(t, history)
+}
+
+func TestBooksProvider_GetBookHistory_NoHistory(t *testing.T) {
+	provider := &booksProvider{}
+
+	history, err := provider.GetBookHistory(1)
+
+	assert.ErrorIs(t, err, ErrBookNotFound)
+	assert.Nil(t, history)
+}
@@ -0,0 +1,226 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+// Data quality rules checked by DataQualityValidator
+const (
+	RuleEmptyName     = "empty_name"
+	RuleMissingAuthor = "missing_author"
+	RuleZeroPrice     = "zero_price"
+	RuleDuplicateID   = "duplicate_id"
+)
+
+var (
+	ErrUnknownQualityRule = errors.New("unknown data quality rule")
+	ErrNoQualityReport    = errors.New("no catalog has been validated yet")
+)
+
+// QualityRule represents a check every book of a catalog should pass. Check
+// returns the position of each offending book.
+type QualityRule struct {
+	Name        string
+	Description string
+	Check       func(books []models.Book) []int
+}
+
+// DataQualityRules lists the built-in rules, in the order they are reported
+var DataQualityRules = []QualityRule{
+	{
+		Name:        RuleEmptyName,
+		Description: "The book has no name",
+		Check:       offendingBooks(func(book models.Book) bool { return strings.TrimSpace(book.Name) == "" }),
+	},
+	{
+		Name:        RuleMissingAuthor,
+		Description: "The book has no author",
+		Check:       offendingBooks(func(book models.Book) bool { return strings.TrimSpace(book.Author) == "" }),
+	},
+	{
+		Name:        RuleZeroPrice,
+		Description: "The book has a price of zero",
+		Check:       offendingBooks(func(book models.Book) bool { return book.Price == 0 }),
+	},
+	{
+		Name:        RuleDuplicateID,
+		Description: "The book ID was already used by a previous book of the catalog",
+		Check:       duplicateIDs,
+	},
+}
+
+// DataQualityConfig configures DataQualityValidator. Rules names the enabled
+// rules, all of them when empty. Books failing a Critical rule are dropped
+// from the catalog when RejectCritical is on.
+type DataQualityConfig struct {
+	Rules          []string
+	Critical       []string
+	RejectCritical bool
+}
+
+// RuleViolation represents the books of a catalog that failed a rule
+type RuleViolation struct {
+	Rule        string `json:"rule" example:"zero_price"`
+	Description string `json:"description" example:"The book has a price of zero"`
+	Critical    bool   `json:"critical" example:"false"`
+	Count       int    `json:"count" example:"2"`
+	BookIDs     []uint `json:"book_ids"`
+}
+
+// DataQualityReport represents the outcome of validating a fetched catalog.
+// Score is the share of books that passed every enabled rule. Decode holds
+// the records the repository had to coerce or drop, when it reports them.
+type DataQualityReport struct {
+	CheckedAt     time.Time                  `json:"checked_at" example:"2025-01-10T12:00:00Z"`
+	TotalBooks    int                        `json:"total_books" example:"50"`
+	ValidBooks    int                        `json:"valid_books" example:"47"`
+	RejectedBooks int                        `json:"rejected_books" example:"1"`
+	Score         float64                    `json:"score" example:"0.94"`
+	Violations    []RuleViolation            `json:"violations"`
+	Decode        *repositories.DecodeReport `json:"decode,omitempty"`
+}
+
+// DataQualityValidator checks fetched catalogs against the configured rules
+// and keeps the report of the latest one
+type DataQualityValidator struct {
+	mu       sync.Mutex
+	config   DataQualityConfig
+	rules    []QualityRule
+	critical map[string]bool
+	report   *DataQualityReport
+}
+
+func NewDataQualityValidator(config DataQualityConfig) (*DataQualityValidator, error) {
+	rules := DataQualityRules
+	if len(config.Rules) > 0 {
+		rules = make([]QualityRule, 0, len(config.Rules))
+		for _, name := range config.Rules {
+			rule, ok := qualityRule(name)
+			if !ok {
+				return nil, fmt.Errorf("%w %q", ErrUnknownQualityRule, name)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	critical := make(map[string]bool, len(config.Critical))
+	for _, name := range config.Critical {
+		if _, ok := qualityRule(name); !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownQualityRule, name)
+		}
+		critical[name] = true
+	}
+
+	return &DataQualityValidator{config: config, rules: rules, critical: critical}, nil
+}
+
+// Validate checks books against the enabled rules and returns the books
+// metrics should use along with the report
+func (v *DataQualityValidator) Validate(books []models.Book, at time.Time) ([]models.Book, DataQualityReport) {
+	failed := make([]bool, len(books))
+	rejected := make([]bool, len(books))
+	report := DataQualityReport{
+		CheckedAt:  at,
+		TotalBooks: len(books),
+		Violations: []RuleViolation{},
+	}
+
+	for _, rule := range v.rules {
+		offending := rule.Check(books)
+		if len(offending) == 0 {
+			continue
+		}
+		violation := RuleViolation{
+			Rule:        rule.Name,
+			Description: rule.Description,
+			Critical:    v.critical[rule.Name],
+			Count:       len(offending),
+			BookIDs:     make([]uint, len(offending)),
+		}
+		for i, index := range offending {
+			violation.BookIDs[i] = books[index].ID
+			failed[index] = true
+			if violation.Critical && v.config.RejectCritical {
+				rejected[index] = true
+			}
+		}
+		report.Violations = append(report.Violations, violation)
+	}
+
+	accepted := make([]models.Book, 0, len(books))
+	for i, book := range books {
+		if !failed[i] {
+			report.ValidBooks++
+		}
+		if rejected[i] {
+			report.RejectedBooks++
+			continue
+		}
+		accepted = append(accepted, book)
+	}
+	report.Score = 1
+	if len(books) > 0 {
+		report.Score = float64(report.ValidBooks) / float64(len(books))
+	}
+
+	v.mu.Lock()
+	v.report = &report
+	v.mu.Unlock()
+	return accepted, report
+}
+
+// Report returns the report of the latest validated catalog
+func (v *DataQualityValidator) Report() (*DataQualityReport, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.report == nil {
+		return nil, ErrNoQualityReport
+	}
+	report := *v.report
+	report.Violations = slices.Clone(report.Violations)
+	return &report, nil
+}
+
+func qualityRule(name string) (QualityRule, bool) {
+	for _, rule := range DataQualityRules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return QualityRule{}, false
+}
+
+// offendingBooks turns a check over a single book into a rule check
+func offendingBooks(fails func(models.Book) bool) func([]models.Book) []int {
+	return func(books []models.Book) []int {
+		var offending []int
+		for i, book := range books {
+			if fails(book) {
+				offending = append(offending, i)
+			}
+		}
+		return offending
+	}
+}
+
+// duplicateIDs flags every book but the first one sharing an ID
+func duplicateIDs(books []models.Book) []int {
+	var offending []int
+	seen := make(map[uint]struct{}, len(books))
+	for i, book := range books {
+		if _, ok := seen[book.ID]; ok {
+			offending = append(offending, i)
+			continue
+		}
+		seen[book.ID] = struct{}{}
+	}
+	return offending
+}
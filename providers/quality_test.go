@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var dirtyCatalog = []models.Book{
+	{ID: 1, Name: "Clean", Author: "A", Price: 10},
+	{ID: 2, Name: "", Author: "A", Price: 10},
+	{ID: 3, Name: "No author", Author: " ", Price: 0},
+	{ID: 1, Name: "Duplicate", Author: "B", Price: 10},
+}
+
+func TestDataQualityValidator_Validate(t *testing.T) {
+	validator, err := NewDataQualityValidator(DataQualityConfig{Critical: []string{RuleDuplicateID}})
+	assert.NoError(t, err)
+
+	books, report := validator.Validate(dirtyCatalog, seriesStart)
+
+	assert.Equal(t, dirtyCatalog, books)
+	assert.Equal(t, seriesStart, report.CheckedAt)
+	assert.Equal(t, 4, report.TotalBooks)
+	assert.Equal(t, 1, report.ValidBooks)
+	assert.Equal(t, 0, report.RejectedBooks)
+	assert.Equal(t, 0.25, report.Score)
+	assert.Equal(t, []RuleViolation{
+		{Rule: RuleEmptyName, Description: "The book has no name", Count: 1, BookIDs: []uint{2}},
+		{Rule: RuleMissingAuthor, Description: "The book has no author", Count: 1, BookIDs: []uint{3}},
+		{Rule: RuleZeroPrice, Description: "The book has a price of zero", Count: 1, BookIDs: []uint{3}},
+		{Rule: RuleDuplicateID, Description: "The book ID was already used by a previous book of the catalog", Critical: true, Count: 1, BookIDs: []uint{1}},
+	}, report.Violations)
+}
+
+func TestDataQualityValidator_RejectCritical(t *testing.T) {
+	validator, err := NewDataQualityValidator(DataQualityConfig{
+		Rules:          []string{RuleEmptyName, RuleDuplicateID, RuleZeroPrice},
+		Critical:       []string{RuleEmptyName, RuleDuplicateID},
+		RejectCritical: true,
+	})
+	assert.NoError(t, err)
+
+	books, report := validator.Validate(dirtyCatalog, seriesStart)
+
+	assert.Equal(t, []models.Book{dirtyCatalog[0], dirtyCatalog[2]}, books)
+	assert.Equal(t, 2, report.RejectedBooks)
+	assert.Equal(t, 1, report.ValidBooks)
+	assert.Len(t, report.Violations, 3)
+}
+
+func TestDataQualityValidator_CleanAndEmptyCatalogs(t *testing.T) {
+	validator, err := NewDataQualityValidator(DataQualityConfig{})
+	assert.NoError(t, err)
+
+	_, report := validator.Validate(nil, seriesStart)
+	assert.Equal(t, 1.0, report.Score)
+	assert.Equal(t, []RuleViolation{}, report.Violations)
+
+	_, report = validator.Validate(dirtyCatalog[:1], seriesStart)
+	assert.Equal(t, 1.0, report.Score)
+	assert.Empty(t, report.Violations)
+}
+
+func TestNewDataQualityValidator_UnknownRule(t *testing.T) {
+	_, err := NewDataQualityValidator(DataQualityConfig{Rules: []string{"negative_price"}})
+	assert.True(t, errors.Is(err, ErrUnknownQualityRule))
+	assert.Contains(t, err.Error(), `"negative_price"`)
+
+	_, err = NewDataQualityValidator(DataQualityConfig{Critical: []string{"nope"}})
+	assert.ErrorIs(t, err, ErrUnknownQualityRule)
+}
+
+func TestBooksProvider_GetDataQuality(t *testing.T) {
+	validator, err := NewDataQualityValidator(DataQualityConfig{Critical: []string{RuleEmptyName}, RejectCritical: true})
+	assert.NoError(t, err)
+	mockRepo := &mockBooksRepository{shouldError: true}
+	provider := &booksProvider{
+		repo:    mockRepo,
+		quality: validator,
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	_, err = provider.GetDataQuality(context.Background())
+	assert.ErrorIs(t, err, ErrNoQualityReport)
+
+	mockRepo.books = dirtyCatalog
+	mockRepo.shouldError = false
+	report, err := provider.GetDataQuality(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.TotalBooks)
+	assert.Equal(t, 1, report.RejectedBooks)
+	assert.Len(t, provider.GetBooks(context.Background(), BooksFilter{}), 3)
+}
+
+type decodeReportingRepository struct {
+	mockBooksRepository
+}
+
+func (r *decodeReportingRepository) LastDecodeReport() (repositories.DecodeReport, bool) {
+	return repositories.DecodeReport{Total: 2, Decoded: 1, Rejected: 1}, true
+}
+
+func TestBooksProvider_GetDataQuality_IncludesDecodeReport(t *testing.T) {
+	validator, err := NewDataQualityValidator(DataQualityConfig{})
+	assert.NoError(t, err)
+	provider := &booksProvider{
+		repo:    &decodeReportingRepository{mockBooksRepository{books: dirtyCatalog[:1]}},
+		quality: validator,
+		logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	report, err := provider.GetDataQuality(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, &repositories.DecodeReport{Total: 2, Decoded: 1, Rejected: 1}, report.Decode)
+}
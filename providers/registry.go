@@ -0,0 +1,180 @@
+package providers
+
+import (
+	"fmt"
+
+	"educabot.com/bookshop/models"
+)
+
+// MetricCalculator computes a single metric over a non-empty list of books
+type MetricCalculator func(books []models.Book, opts MetricsOptions) any
+
+// Metric represents a named calculator that can be selected through the fields parameter
+type Metric struct {
+	Name        string           `json:"name" example:"mean_units_sold"`
+	Description string           `json:"description" example:"Mean units sold per book"`
+	Default     bool             `json:"default" example:"true"`
+	Calculate   MetricCalculator `json:"-"`
+	// Empty is written when no book matched, the metric is left out when nil
+	Empty any `json:"-"`
+}
+
+// MetricRegistry holds the metrics available to GetMetrics, in registration order
+type MetricRegistry struct {
+	metrics []Metric
+	byName  map[string]int
+}
+
+func NewMetricRegistry() *MetricRegistry {
+	return &MetricRegistry{byName: make(map[string]int)}
+}
+
+// Register adds a metric to the registry. Names must be unique, the value the
+// calculator returns is written under the name.
+func (r *MetricRegistry) Register(metric Metric) error {
+	if metric.Name == "" || metric.Calculate == nil {
+		return fmt.Errorf("metric must have a name and a calculator")
+	}
+	if _, ok := r.byName[metric.Name]; ok {
+		return fmt.Errorf("metric %q already registered", metric.Name)
+	}
+	r.byName[metric.Name] = len(r.metrics)
+	r.metrics = append(r.metrics, metric)
+	return nil
+}
+
+// Metrics returns every registered metric
+func (r *MetricRegistry) Metrics() []Metric {
+	metrics := make([]Metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	return metrics
+}
+
+// Defaults returns the names of the metrics computed when none is requested
+func (r *MetricRegistry) Defaults() []string {
+	var names []string
+	for _, metric := range r.metrics {
+		if metric.Default {
+			names = append(names, metric.Name)
+		}
+	}
+	return names
+}
+
+// Lookup returns the metrics with the given names, in registration order
+func (r *MetricRegistry) Lookup(names []string) ([]Metric, error) {
+	selected := make([]bool, len(r.metrics))
+	for _, name := range names {
+		i, ok := r.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownMetric, name)
+		}
+		selected[i] = true
+	}
+
+	var metrics []Metric
+	for i, metric := range r.metrics {
+		if selected[i] {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+// DefaultMetricRegistry holds the built-in metrics
+var DefaultMetricRegistry = newDefaultMetricRegistry()
+
+func newDefaultMetricRegistry() *MetricRegistry {
+	registry := NewMetricRegistry()
+	for _, metric := range []Metric{
+		{
+			Name:        "mean_units_sold",
+			Description: "Mean units sold per book, rounded down",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return meanUnitsSold(books)
+			},
+			Empty: uint(0),
+		},
+		{
+			Name:        "cheapest_book",
+			Description: "Name of the cheapest book, the lowest ID wins ties",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return cheapestBooks(books)[0].Name
+			},
+			Empty: "",
+		},
+		{
+			Name:        "books_written_by_author",
+			Description: "Number of books written by the requested author",
+			Default:     true,
+			Calculate: func(books []models.Book, opts MetricsOptions) any {
+				return booksWrittenByAuthor(books, opts.Author)
+			},
+			Empty: uint(0),
+		},
+		{
+			Name:        "cheapest_books",
+			Description: "Names of every book tied for the lowest price",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return bookNames(cheapestBooks(books))
+			},
+			Empty: []string{},
+		},
+		{
+			Name:        "most_expensive_books",
+			Description: "Names of every book tied for the highest price",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return bookNames(mostExpensiveBooks(books))
+			},
+			Empty: []string{},
+		},
+		{
+			Name:        "best_sellers",
+			Description: "Names of every book tied for the most units sold",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return bookNames(bestSellers(books))
+			},
+			Empty: []string{},
+		},
+		{
+			Name:        "worst_sellers",
+			Description: "Names of every book tied for the fewest units sold",
+			Default:     true,
+			Calculate: func(books []models.Book, _ MetricsOptions) any {
+				return bookNames(worstSellers(books))
+			},
+			Empty: []string{},
+		},
+		{
+			Name:        "units_sold_stats",
+			Description: "Distribution statistics of units sold, every statistic unless stats is set",
+			Calculate: func(books []models.Book, opts MetricsOptions) any {
+				return distribution(bookValues(books, bookUnitsSold), statsOrAll(opts.Stats))
+			},
+		},
+		{
+			Name:        "price_stats",
+			Description: "Distribution statistics of price, every statistic unless stats is set",
+			Calculate: func(books []models.Book, opts MetricsOptions) any {
+				return distribution(bookValues(books, bookPrice), statsOrAll(opts.Stats))
+			},
+		},
+	} {
+		if err := registry.Register(metric); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+func statsOrAll(stats []string) []string {
+	if len(stats) == 0 {
+		return AvailableStats
+	}
+	return stats
+}
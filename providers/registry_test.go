@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricRegistry_Register(t *testing.T) {
+	registry := NewMetricRegistry()
+	calculate := func([]models.Book, MetricsOptions) any { return nil }
+
+	assert.NoError(t, registry.Register(Metric{Name: "a", Default: true, Calculate: calculate}))
+	assert.NoError(t, registry.Register(Metric{Name: "b", Calculate: calculate}))
+	assert.Error(t, registry.Register(Metric{Name: "a", Calculate: calculate}))
+	assert.Error(t, registry.Register(Metric{Name: "c"}))
+	assert.Error(t, registry.Register(Metric{Calculate: calculate}))
+
+	assert.Equal(t, []string{"a"}, registry.Defaults())
+	assert.Len(t, registry.Metrics(), 2)
+}
+
+func TestMetricRegistry_Lookup(t *testing.T) {
+	registry := NewMetricRegistry()
+	calculate := func([]models.Book, MetricsOptions) any { return nil }
+	registry.Register(Metric{Name: "a", Calculate: calculate})
+	registry.Register(Metric{Name: "b", Calculate: calculate})
+
+	metrics, err := registry.Lookup([]string{"b", "a", "b"})
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "a", metrics[0].Name)
+	assert.Equal(t, "b", metrics[1].Name)
+
+	metrics, err = registry.Lookup([]string{"a", "z"})
+	assert.ErrorIs(t, err, ErrUnknownMetric)
+	assert.EqualError(t, err, `unknown metric "z"`)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksMetrics_MarshalJSON(t *testing.T) {
+	metrics := BooksMetrics{MatchedBooks: 2}
+	metrics.Set("worst_sellers", []string{"Book 1"})
+	metrics.Set("mean_units_sold", uint(10))
+	metrics.Set("worst_sellers", []string{"Book 2"})
+
+	data, err := json.Marshal(metrics)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"worst_sellers":["Book 2"],"mean_units_sold":10,"filter":{},"matched_books":2}`, string(data))
+	assert.Equal(t, uint(10), metrics.Value("mean_units_sold"))
+	assert.Nil(t, metrics.Value("cheapest_book"))
+}
+
+func TestBooksMetrics_MarshalJSON_NoMetrics(t *testing.T) {
+	data, err := json.Marshal(BooksMetrics{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"filter":{},"matched_books":0}`, string(data))
+}
+
+func TestBooksProvider_GetMetrics_Fields(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Name: "Book 1", Author: "Author 1", UnitsSold: 100, Price: 20},
+			{ID: 2, Name: "Book 2", Author: "Author 2", UnitsSold: 300, Price: 10},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Fields: []string{"best_sellers", "mean_units_sold", "price_stats"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(200), metrics.Value("mean_units_sold"))
+	assert.Equal(t, []string{"Book 2"}, metrics.Value("best_sellers"))
+	assert.Nil(t, metrics.Value("cheapest_book"))
+	assert.Len(t, metrics.Value("price_stats"), len(AvailableStats))
+	assert.Nil(t, metrics.Value("units_sold_stats"))
+
+	data, err := json.Marshal(metrics)
+	assert.NoError(t, err)
+	var fields map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Len(t, fields, 5)
+	assert.JSONEq(t, "2", string(fields["matched_books"]))
+	assert.JSONEq(t, "{}", string(fields["filter"]))
+	assert.JSONEq(t, "200", string(fields["mean_units_sold"]))
+	assert.JSONEq(t, `["Book 2"]`, string(fields["best_sellers"]))
+	assert.Contains(t, fields, "price_stats")
+}
+
+func TestBooksProvider_GetMetrics_DefaultFieldsJSON(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20}}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{})
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(metrics)
+	assert.NoError(t, err)
+	var fields map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	// every default metric plus the echoed filter and matched book count
+	assert.Len(t, fields, len(DefaultMetricRegistry.Defaults())+2)
+}
+
+func TestBooksProvider_GetMetrics_EveryFieldJSON(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20}}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, metric := range DefaultMetricRegistry.Metrics() {
+		metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Fields: []string{metric.Name}})
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(metrics)
+		assert.NoError(t, err)
+		var fields map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(data, &fields))
+		assert.Contains(t, fields, metric.Name)
+	}
+}
+
+func TestBooksProvider_GetMetrics_UnknownField(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetMetrics(context.Background(), MetricsOptions{Fields: []string{"median_rating"}})
+
+	assert.ErrorIs(t, err, ErrUnknownMetric)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksProvider_GetMetricsCatalog(t *testing.T) {
+	provider := &booksProvider{}
+
+	catalog := provider.GetMetricsCatalog()
+
+	assert.Len(t, catalog, len(DefaultMetricRegistry.Metrics()))
+	assert.Equal(t, "mean_units_sold", catalog[0].Name)
+	assert.True(t, catalog[0].Default)
+	assert.Equal(t, "price_stats", catalog[len(catalog)-1].Name)
+	assert.False(t, catalog[len(catalog)-1].Default)
+}
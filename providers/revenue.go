@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/bits"
+	"slices"
+
+	"educabot.com/bookshop/models"
+)
+
+// DefaultRevenueTop is the number of top revenue titles returned when none is requested
+const DefaultRevenueTop = 10
+
+var ErrRevenueOverflow = errors.New("revenue overflows uint64")
+
+// BookRevenue represents the revenue of a single book and its share of the total
+type BookRevenue struct {
+	ID      uint    `json:"id" example:"1"`
+	Name    string  `json:"name" example:"The Go Programming Language"`
+	Author  string  `json:"author" example:"Alan Donovan"`
+	Revenue uint64  `json:"revenue" example:"225000"`
+	Share   float64 `json:"share" example:"0.25"`
+}
+
+// RevenueMetrics represents revenue metrics computed from price × units sold.
+// Books whose revenue would overflow the totals are left out and listed in
+// OverflowedBooks.
+type RevenueMetrics struct {
+	TotalRevenue    uint64            `json:"total_revenue" example:"900000"`
+	RevenueByAuthor map[string]uint64 `json:"revenue_by_author"`
+	TopTitles       []BookRevenue     `json:"top_titles"`
+	Books           []BookRevenue     `json:"books"`
+	OverflowedBooks []uint            `json:"overflowed_books,omitempty" example:"7"`
+}
+
+func (p *booksProvider) GetRevenueMetrics(ctx context.Context, top int) (*RevenueMetrics, error) {
+	if top <= 0 {
+		top = DefaultRevenueTop
+	}
+
+	books, err := p.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metrics := revenueMetrics(books, top)
+	if len(metrics.OverflowedBooks) > 0 {
+		p.logger.WarnContext(ctx, "Left books out of the revenue metrics", "error", ErrRevenueOverflow, "book_ids", metrics.OverflowedBooks)
+	}
+	return metrics, nil
+}
+
+func revenueMetrics(books []models.Book, top int) *RevenueMetrics {
+	revenues := make([]BookRevenue, 0, len(books))
+	byAuthor := make(map[string]uint64)
+	var total uint64
+	var overflowed []uint
+
+	for _, book := range books {
+		// the author total never exceeds the total, so it cannot overflow
+		// once the total does not
+		revenue, err := bookRevenue(book)
+		var sum uint64
+		if err == nil {
+			sum, err = addRevenue(total, revenue)
+		}
+		if err != nil {
+			overflowed = append(overflowed, book.ID)
+			continue
+		}
+		total = sum
+		byAuthor[book.Author] += revenue
+		revenues = append(revenues, BookRevenue{ID: book.ID, Name: book.Name, Author: book.Author, Revenue: revenue})
+	}
+
+	for i := range revenues {
+		if total > 0 {
+			revenues[i].Share = float64(revenues[i].Revenue) / float64(total)
+		}
+	}
+
+	slices.SortStableFunc(revenues, func(a, b BookRevenue) int {
+		if c := cmp.Compare(b.Revenue, a.Revenue); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return &RevenueMetrics{
+		TotalRevenue:    total,
+		RevenueByAuthor: byAuthor,
+		TopTitles:       slices.Clone(revenues[:min(top, len(revenues))]),
+		Books:           revenues,
+		OverflowedBooks: overflowed,
+	}
+}
+
+func bookRevenue(book models.Book) (uint64, error) {
+	hi, lo := bits.Mul64(uint64(book.Price), uint64(book.UnitsSold))
+	if hi != 0 {
+		return 0, ErrRevenueOverflow
+	}
+	return lo, nil
+}
+
+func addRevenue(a, b uint64) (uint64, error) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return 0, ErrRevenueOverflow
+	}
+	return sum, nil
+}
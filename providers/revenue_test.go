@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksProvider_GetRevenueMetrics_OK(t *testing.T) {
+	mockRepo := &mockBooksRepository{
+		books: []models.Book{
+			{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
+			{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 1000, Price: 50},
+			{ID: 3, Name: "Clean Architecture", Author: "Robert C. Martin", UnitsSold: 2000, Price: 25},
+			{ID: 4, Name: "Unsold", Author: "Nobody", UnitsSold: 0, Price: 30},
+		},
+	}
+
+	provider := &booksProvider{
+		repo:   mockRepo,
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300000), metrics.TotalRevenue)
+	assert.Equal(t, map[string]uint64{"Alan Donovan": 200000, "Robert C. Martin": 100000, "Nobody": 0}, metrics.RevenueByAuthor)
+
+	assert.Len(t, metrics.TopTitles, 2)
+	assert.Equal(t, uint(1), metrics.TopTitles[0].ID)
+	// Clean Code and Clean Architecture tie at 50000, the lowest ID comes first
+	assert.Equal(t, uint(2), metrics.TopTitles[1].ID)
+
+	assert.Len(t, metrics.Books, 4)
+	assert.InDelta(t, 2.0/3.0, metrics.Books[0].Share, 1e-9)
+	assert.InDelta(t, 1.0/6.0, metrics.Books[1].Share, 1e-9)
+	assert.InDelta(t, 1.0/6.0, metrics.Books[2].Share, 1e-9)
+	assert.Equal(t, 0.0, metrics.Books[3].Share)
+}
+
+func TestBooksProvider_GetRevenueMetrics_DefaultTop(t *testing.T) {
+	books := make([]models.Book, DefaultRevenueTop+5)
+	for i := range books {
+		books[i] = models.Book{ID: uint(i + 1), UnitsSold: 1, Price: 1}
+	}
+
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: books},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, metrics.TopTitles, DefaultRevenueTop)
+}
+
+func TestBooksProvider_GetRevenueMetrics_EmptyBooks(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{books: []models.Book{}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), metrics.TotalRevenue)
+	assert.Empty(t, metrics.TopTitles)
+	assert.Empty(t, metrics.Books)
+}
+
+func TestBooksProvider_GetRevenueMetrics_RepositoryError(t *testing.T) {
+	provider := &booksProvider{
+		repo:   &mockBooksRepository{shouldError: true},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 5)
+
+	assert.ErrorIs(t, err, ErrCatalogUnavailable)
+	assert.Nil(t, metrics)
+}
+
+func TestBooksProvider_GetRevenueMetrics_BookOverflow(t *testing.T) {
+	provider := &booksProvider{
+		repo: &mockBooksRepository{books: []models.Book{
+			{ID: 1, UnitsSold: math.MaxUint64, Price: 2},
+			{ID: 2, UnitsSold: 3, Price: 10},
+		}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(30), metrics.TotalRevenue)
+	assert.Equal(t, []uint{1}, metrics.OverflowedBooks)
+	assert.Len(t, metrics.Books, 1)
+	assert.Equal(t, 1.0, metrics.Books[0].Share)
+}
+
+func TestBooksProvider_GetRevenueMetrics_TotalOverflow(t *testing.T) {
+	provider := &booksProvider{
+		repo: &mockBooksRepository{books: []models.Book{
+			{ID: 1, UnitsSold: math.MaxUint64, Price: 1},
+			{ID: 2, UnitsSold: 1, Price: 1},
+		}},
+		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	metrics, err := provider.GetRevenueMetrics(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), metrics.TotalRevenue)
+	assert.Equal(t, map[string]uint64{"": math.MaxUint64}, metrics.RevenueByAuthor)
+	assert.Equal(t, []uint{2}, metrics.OverflowedBooks)
+}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"slices"
+
+	"educabot.com/bookshop/models"
+)
+
+// Statistics that can be requested through the stats parameter
+const (
+	StatMin    = "min"
+	StatMax    = "max"
+	StatMean   = "mean"
+	StatMedian = "median"
+	StatP90    = "p90"
+	StatP95    = "p95"
+	StatP99    = "p99"
+	StatStdDev = "stddev"
+	StatIQR    = "iqr"
+)
+
+// AvailableStats lists every statistic supported by Distribution, in response order
+var AvailableStats = []string{StatMin, StatMax, StatMean, StatMedian, StatP90, StatP95, StatP99, StatStdDev, StatIQR}
+
+// statsPrecision is the mantissa size of the intermediate results of
+// distribution, enough to hold the square of any uint
+const statsPrecision = 256
+
+var ErrUnknownStat = errors.New("unknown statistic")
+
+// Distribution maps a statistic name to its value
+type Distribution map[string]float64
+
+// validateStats checks that every requested statistic is supported
+func validateStats(stats []string) error {
+	for _, stat := range stats {
+		if !slices.Contains(AvailableStats, stat) {
+			return ErrUnknownStat
+		}
+	}
+	return nil
+}
+
+func unitsSoldValues(books []models.Book) []float64 {
+	values := make([]float64, len(books))
+	for i, book := range books {
+		values[i] = float64(book.UnitsSold)
+	}
+	return values
+}
+
+// bookValues applies value to every book
+func bookValues(books []models.Book, value func(models.Book) uint) []uint {
+	values := make([]uint, len(books))
+	for i, book := range books {
+		values[i] = value(book)
+	}
+	return values
+}
+
+// distribution computes the requested statistics over values. Sums and
+// interpolations are exact, each statistic is rounded to a float64 once.
+// Percentiles use linear interpolation between the closest ranks and the
+// standard deviation is the population one.
+func distribution(values []uint, stats []string) Distribution {
+	if len(values) == 0 || len(stats) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := big.NewInt(int64(len(sorted)))
+	sum, squares := new(big.Int), new(big.Int)
+	for _, value := range sorted {
+		v := new(big.Int).SetUint64(uint64(value))
+		sum.Add(sum, v)
+		squares.Add(squares, v.Mul(v, v))
+	}
+
+	result := make(Distribution, len(stats))
+	for _, stat := range stats {
+		switch stat {
+		case StatMin:
+			result[stat] = float64(sorted[0])
+		case StatMax:
+			result[stat] = float64(sorted[len(sorted)-1])
+		case StatMean:
+			result[stat], _ = new(big.Rat).SetFrac(sum, n).Float64()
+		case StatMedian:
+			result[stat], _ = exactPercentile(sorted, 0.5).Float64()
+		case StatP90:
+			result[stat], _ = exactPercentile(sorted, 0.9).Float64()
+		case StatP95:
+			result[stat], _ = exactPercentile(sorted, 0.95).Float64()
+		case StatP99:
+			result[stat], _ = exactPercentile(sorted, 0.99).Float64()
+		case StatStdDev:
+			result[stat] = populationStdDev(n, sum, squares)
+		case StatIQR:
+			iqr := exactPercentile(sorted, 0.75)
+			result[stat], _ = iqr.Sub(iqr, exactPercentile(sorted, 0.25)).Float64()
+		}
+	}
+	return result
+}
+
+// populationStdDev returns sqrt(n·Σx² − (Σx)²) / n
+func populationStdDev(n, sum, squares *big.Int) float64 {
+	variance := new(big.Int).Mul(n, squares)
+	variance.Sub(variance, new(big.Int).Mul(sum, sum))
+	root := new(big.Float).SetPrec(statsPrecision).SetInt(variance)
+	root.Sqrt(root)
+	stddev, _ := root.Quo(root, new(big.Float).SetInt(n)).Float64()
+	return stddev
+}
+
+// exactPercentile expects sorted values and q in [0, 1]
+func exactPercentile(sorted []uint, q float64) *big.Float {
+	rank := q * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	value := new(big.Float).SetPrec(statsPrecision).SetUint64(uint64(sorted[lower]))
+	if lower == upper {
+		return value
+	}
+	step := new(big.Float).SetPrec(statsPrecision).SetUint64(uint64(sorted[upper] - sorted[lower]))
+	step.Mul(step, big.NewFloat(rank-float64(lower)))
+	return value.Add(value, step)
+}
+
+// meanAndStdDev uses Welford's algorithm, which avoids the cancellation of
+// subtracting the squared mean from the mean of squares
+func meanAndStdDev(values []float64) (float64, float64) {
+	var mean, m2 float64
+	for i, value := range values {
+		delta := value - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (value - mean)
+	}
+	return mean, math.Sqrt(m2 / float64(len(values)))
+}
+
+// percentile expects sorted values and q in [0, 1]
+func percentile(sorted []float64, q float64) float64 {
+	rank := q * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
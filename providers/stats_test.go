@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistribution_AllStats(t *testing.T) {
+	values := []uint{7, 1, 3, 5, 9, 2, 4, 6, 8, 10}
+
+	stats := distribution(values, AvailableStats)
+
+	assert.Equal(t, 1.0, stats[StatMin])
+	assert.Equal(t, 10.0, stats[StatMax])
+	assert.Equal(t, 5.5, stats[StatMean])
+	assert.Equal(t, 5.5, stats[StatMedian])
+	assert.InDelta(t, 9.1, stats[StatP90], 1e-9)
+	assert.InDelta(t, 9.55, stats[StatP95], 1e-9)
+	assert.InDelta(t, 9.91, stats[StatP99], 1e-9)
+	assert.InDelta(t, 2.8722813232690143, stats[StatStdDev], 1e-9)
+	assert.InDelta(t, 4.5, stats[StatIQR], 1e-9)
+}
+
+func TestDistribution_SingleValue(t *testing.T) {
+	stats := distribution([]uint{42}, AvailableStats)
+
+	for _, stat := range AvailableStats {
+		switch stat {
+		case StatStdDev, StatIQR:
+			assert.Equal(t, 0.0, stats[stat], stat)
+		default:
+			assert.Equal(t, 42.0, stats[stat], stat)
+		}
+	}
+}
+
+func TestDistribution_OnlyRequestedStats(t *testing.T) {
+	stats := distribution([]uint{1, 2, 3}, []string{StatMedian})
+
+	assert.Equal(t, Distribution{StatMedian: 2}, stats)
+}
+
+func TestDistribution_LargeValues(t *testing.T) {
+	// converting to float64 first would round these to 2^53 and 2^53+4
+	values := []uint{1<<53 + 1, 1<<53 + 3}
+
+	stats := distribution(values, []string{StatMean, StatMedian, StatStdDev, StatIQR})
+
+	assert.Equal(t, float64(1<<53+2), stats[StatMean])
+	assert.Equal(t, float64(1<<53+2), stats[StatMedian])
+	assert.Equal(t, 1.0, stats[StatStdDev])
+	assert.Equal(t, 1.0, stats[StatIQR])
+}
+
+func TestDistribution_Empty(t *testing.T) {
+	assert.Nil(t, distribution(nil, AvailableStats))
+	assert.Nil(t, distribution([]uint{1, 2}, nil))
+}
+
+func TestValidateStats(t *testing.T) {
+	assert.NoError(t, validateStats(AvailableStats))
+	assert.ErrorIs(t, validateStats([]string{StatMedian, "mode"}), ErrUnknownStat)
+}
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"educabot.com/bookshop/models"
+)
+
+const (
+	// MaxTimeSeriesPoints caps the number of points of a single time series
+	MaxTimeSeriesPoints    = 1000
+	defaultTimeSeriesRange = 7 * 24 * time.Hour
+	defaultTimeSeriesStep  = 24 * time.Hour
+)
+
+var ErrInvalidTimeSeries = errors.New("invalid time series parameters")
+
+// TimeSeriesOptions represents the parameters of a time series request. Zero
+// values default to the last seven days at daily intervals.
+type TimeSeriesOptions struct {
+	From     time.Time
+	To       time.Time
+	Interval time.Duration
+}
+
+// TimeSeriesDelta represents the change of each value since the previous point.
+// Percentages are omitted when the previous value is zero.
+type TimeSeriesDelta struct {
+	MeanUnitsSold    float64  `json:"mean_units_sold" example:"250.5"`
+	MeanUnitsSoldPct *float64 `json:"mean_units_sold_pct,omitempty" example:"2.5"`
+	TotalRevenue     float64  `json:"total_revenue" example:"12000"`
+	TotalRevenuePct  *float64 `json:"total_revenue_pct,omitempty" example:"1.2"`
+	BookCount        int      `json:"book_count" example:"1"`
+	BookCountPct     *float64 `json:"book_count_pct,omitempty" example:"10"`
+}
+
+// TimeSeriesPoint represents the metrics of the catalog version in effect at a
+// point in time. OverflowedBooks lists the books left out of TotalRevenue
+// because it would overflow.
+type TimeSeriesPoint struct {
+	At              time.Time        `json:"at" example:"2025-01-10T00:00:00Z"`
+	Version         int              `json:"version" example:"3"`
+	MeanUnitsSold   float64          `json:"mean_units_sold" example:"10000.5"`
+	TotalRevenue    uint64           `json:"total_revenue" example:"900000"`
+	BookCount       int              `json:"book_count" example:"10"`
+	Delta           *TimeSeriesDelta `json:"delta,omitempty"`
+	OverflowedBooks []uint           `json:"overflowed_books,omitempty" example:"7"`
+}
+
+// TimeSeries represents catalog metrics sampled at regular intervals
+type TimeSeries struct {
+	From     time.Time         `json:"from" example:"2025-01-03T00:00:00Z"`
+	To       time.Time         `json:"to" example:"2025-01-10T00:00:00Z"`
+	Interval string            `json:"interval" example:"24h0m0s"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+func (p *booksProvider) GetTimeSeries(opts TimeSeriesOptions) (*TimeSeries, error) {
+	opts = opts.withDefaults(time.Now())
+	if !opts.From.Before(opts.To) || opts.Interval <= 0 {
+		return nil, fmt.Errorf("%w: from must be before to and interval positive", ErrInvalidTimeSeries)
+	}
+	if opts.To.Sub(opts.From)/opts.Interval >= MaxTimeSeriesPoints {
+		return nil, fmt.Errorf("%w: more than %d points requested", ErrInvalidTimeSeries, MaxTimeSeriesPoints)
+	}
+
+	var versions []models.CatalogVersion
+	if p.history != nil {
+		versions = p.history.Versions()
+	}
+
+	series := &TimeSeries{
+		From:     opts.From,
+		To:       opts.To,
+		Interval: opts.Interval.String(),
+		Points:   []TimeSeriesPoint{},
+	}
+	for at := opts.From; !at.After(opts.To); at = at.Add(opts.Interval) {
+		version, ok := versionAt(versions, at)
+		if !ok {
+			continue
+		}
+		point := timeSeriesPoint(version, at)
+		if len(series.Points) > 0 {
+			point.Delta = timeSeriesDelta(series.Points[len(series.Points)-1], point)
+		}
+		series.Points = append(series.Points, point)
+	}
+	return series, nil
+}
+
+func (o TimeSeriesOptions) withDefaults(now time.Time) TimeSeriesOptions {
+	if o.To.IsZero() {
+		o.To = now
+	}
+	if o.From.IsZero() {
+		o.From = o.To.Add(-defaultTimeSeriesRange)
+	}
+	if o.Interval == 0 {
+		o.Interval = defaultTimeSeriesStep
+	}
+	return o
+}
+
+// versionAt returns the latest version fetched at or before at. Versions must be
+// ordered oldest first.
+func versionAt(versions []models.CatalogVersion, at time.Time) (models.CatalogVersion, bool) {
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].FetchedAt.After(at)
+	})
+	if i == 0 {
+		return models.CatalogVersion{}, false
+	}
+	return versions[i-1], true
+}
+
+func timeSeriesPoint(version models.CatalogVersion, at time.Time) TimeSeriesPoint {
+	point := TimeSeriesPoint{At: at, Version: version.Version, BookCount: len(version.Books)}
+	if len(version.Books) == 0 {
+		return point
+	}
+
+	mean, _ := meanAndStdDev(unitsSoldValues(version.Books))
+	point.MeanUnitsSold = mean
+
+	for _, book := range version.Books {
+		revenue, err := bookRevenue(book)
+		if err == nil {
+			var total uint64
+			if total, err = addRevenue(point.TotalRevenue, revenue); err == nil {
+				point.TotalRevenue = total
+			}
+		}
+		if err != nil {
+			point.OverflowedBooks = append(point.OverflowedBooks, book.ID)
+		}
+	}
+	return point
+}
+
+func timeSeriesDelta(previous, current TimeSeriesPoint) *TimeSeriesDelta {
+	return &TimeSeriesDelta{
+		MeanUnitsSold:    current.MeanUnitsSold - previous.MeanUnitsSold,
+		MeanUnitsSoldPct: percentChange(previous.MeanUnitsSold, current.MeanUnitsSold),
+		TotalRevenue:     float64(current.TotalRevenue) - float64(previous.TotalRevenue),
+		TotalRevenuePct:  percentChange(float64(previous.TotalRevenue), float64(current.TotalRevenue)),
+		BookCount:        current.BookCount - previous.BookCount,
+		BookCountPct:     percentChange(float64(previous.BookCount), float64(current.BookCount)),
+	}
+}
+
+func percentChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	pct := (current - previous) / previous * 100
+	return &pct
+}
@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var seriesStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newHistoryProvider(t *testing.T, catalogs map[time.Duration][]models.Book) *booksProvider {
+	t.Helper()
+	history := repositories.NewInMemoryHistoryRepository(repositories.HistoryRetention{})
+	offsets := make([]time.Duration, 0, len(catalogs))
+	for offset := range catalogs {
+		offsets = append(offsets, offset)
+	}
+	slices.Sort(offsets)
+	for _, offset := range offsets {
+		history.Record(catalogs[offset], seriesStart.Add(offset))
+	}
+	return &booksProvider{history: history}
+}
+
+func TestBooksProvider_GetTimeSeries_OK(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		day: {
+			{ID: 1, UnitsSold: 100, Price: 10},
+			{ID: 2, UnitsSold: 201, Price: 20},
+		},
+		3 * day: {
+			{ID: 1, UnitsSold: 150, Price: 10},
+			{ID: 2, UnitsSold: 250, Price: 20},
+			{ID: 3, UnitsSold: 20, Price: 5},
+		},
+	})
+
+	series, err := provider.GetTimeSeries(TimeSeriesOptions{
+		From:     seriesStart,
+		To:       seriesStart.Add(4 * day),
+		Interval: day,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "24h0m0s", series.Interval)
+	// nothing was recorded before the first day
+	assert.Len(t, series.Points, 4)
+
+	first := series.Points[0]
+	assert.Equal(t, seriesStart.Add(day), first.At)
+	assert.Equal(t, 1, first.Version)
+	assert.Equal(t, 150.5, first.MeanUnitsSold)
+	assert.Equal(t, uint64(5020), first.TotalRevenue)
+	assert.Equal(t, 2, first.BookCount)
+	assert.Nil(t, first.Delta)
+
+	assert.Equal(t, 1, series.Points[1].Version)
+	assert.Equal(t, 0.0, series.Points[1].Delta.MeanUnitsSold)
+	assert.Equal(t, 0.0, *series.Points[1].Delta.TotalRevenuePct)
+
+	third := series.Points[2]
+	assert.Equal(t, 2, third.Version)
+	assert.Equal(t, 140.0, third.MeanUnitsSold)
+	assert.Equal(t, uint64(6600), third.TotalRevenue)
+	assert.Equal(t, -10.5, third.Delta.MeanUnitsSold)
+	assert.Equal(t, 1580.0, third.Delta.TotalRevenue)
+	assert.InDelta(t, 1580.0/5020.0*100, *third.Delta.TotalRevenuePct, 1e-9)
+	assert.Equal(t, 1, third.Delta.BookCount)
+	assert.Equal(t, 50.0, *third.Delta.BookCountPct)
+}
+
+func TestBooksProvider_GetTimeSeries_PercentFromZero(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:   {},
+		day: {{ID: 1, UnitsSold: 10, Price: 2}},
+	})
+
+	series, err := provider.GetTimeSeries(TimeSeriesOptions{From: seriesStart, To: seriesStart.Add(day), Interval: day})
+
+	assert.NoError(t, err)
+	assert.Len(t, series.Points, 2)
+	assert.Equal(t, 1, series.Points[1].Delta.BookCount)
+	assert.Nil(t, series.Points[1].Delta.BookCountPct)
+	assert.Nil(t, series.Points[1].Delta.TotalRevenuePct)
+}
+
+func TestBooksProvider_GetTimeSeries_NoHistory(t *testing.T) {
+	provider := &booksProvider{}
+
+	series, err := provider.GetTimeSeries(TimeSeriesOptions{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, series.Points)
+	assert.Equal(t, 7*24*time.Hour, series.To.Sub(series.From))
+}
+
+func TestBooksProvider_GetTimeSeries_RevenueOverflow(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0: {{ID: 1, UnitsSold: math.MaxUint64, Price: 2}, {ID: 2, UnitsSold: 3, Price: 10}},
+	})
+
+	series, err := provider.GetTimeSeries(TimeSeriesOptions{From: seriesStart, To: seriesStart.Add(time.Hour), Interval: time.Hour})
+
+	assert.NoError(t, err)
+	assert.Len(t, series.Points, 2)
+	assert.Equal(t, uint64(30), series.Points[0].TotalRevenue)
+	assert.Equal(t, []uint{1}, series.Points[0].OverflowedBooks)
+}
+
+func TestBooksProvider_GetTimeSeries_InvalidOptions(t *testing.T) {
+	provider := &booksProvider{}
+
+	tests := []struct {
+		name string
+		opts TimeSeriesOptions
+	}{
+		{"from after to", TimeSeriesOptions{From: seriesStart.Add(time.Hour), To: seriesStart, Interval: time.Minute}},
+		{"negative interval", TimeSeriesOptions{From: seriesStart, To: seriesStart.Add(time.Hour), Interval: -time.Minute}},
+		{"too many points", TimeSeriesOptions{From: seriesStart, To: seriesStart.Add(24 * time.Hour), Interval: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := provider.GetTimeSeries(tt.opts)
+			assert.ErrorIs(t, err, ErrInvalidTimeSeries)
+			assert.Nil(t, series)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package providers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+// Bestseller movements between two points in time
+const (
+	MovementUp   = "up"
+	MovementDown = "down"
+	MovementSame = "same"
+	MovementNew  = "new"
+)
+
+const (
+	DefaultTrendingLimit = 10
+	MaxTrendingLimit     = 100
+	defaultTrendingRange = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidTrending  = errors.New("invalid trending parameters")
+	ErrNotEnoughHistory = errors.New("not enough catalog history")
+)
+
+// TrendingOptions represents the parameters of a trending request. Zero values
+// default to the last seven days and the top ten books.
+type TrendingOptions struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// TrendingBook represents the sales velocity of a book and how its bestseller
+// rank moved between the two points in time
+type TrendingBook struct {
+	ID                     uint    `json:"id" example:"1"`
+	Name                   string  `json:"name" example:"The Go Programming Language"`
+	Author                 string  `json:"author" example:"Alan Donovan"`
+	Rank                   int     `json:"rank" example:"1"`
+	UnitsSold              uint    `json:"units_sold" example:"5000"`
+	UnitsSoldInPeriod      int64   `json:"units_sold_in_period" example:"700"`
+	UnitsPerDay            float64 `json:"units_per_day" example:"100"`
+	BestsellerRank         int     `json:"bestseller_rank" example:"2"`
+	PreviousBestsellerRank int     `json:"previous_bestseller_rank,omitempty" example:"4"`
+	Movement               string  `json:"movement" example:"up"`
+}
+
+// Trending represents the books ranked by units sold per day between two catalog versions
+type Trending struct {
+	From        time.Time      `json:"from" example:"2025-01-03T00:00:00Z"`
+	To          time.Time      `json:"to" example:"2025-01-10T00:00:00Z"`
+	FromVersion int            `json:"from_version" example:"2"`
+	ToVersion   int            `json:"to_version" example:"5"`
+	Books       []TrendingBook `json:"books"`
+}
+
+func (p *booksProvider) GetTrending(opts TrendingOptions) (*Trending, error) {
+	opts = opts.withDefaults(time.Now())
+	if !opts.From.Before(opts.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidTrending)
+	}
+	if opts.Limit < 0 || opts.Limit > MaxTrendingLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidTrending, MaxTrendingLimit)
+	}
+
+	var versions []models.CatalogVersion
+	if p.history != nil {
+		versions = p.history.Versions()
+	}
+	to, ok := versionAt(versions, opts.To)
+	if !ok {
+		return nil, ErrNotEnoughHistory
+	}
+	from, ok := versionAt(versions, opts.From)
+	if !ok {
+		// the period starts before the first recorded version, use it instead
+		from = versions[0]
+	}
+	if from.Version == to.Version {
+		return nil, ErrNotEnoughHistory
+	}
+
+	first := slices.IndexFunc(versions, func(v models.CatalogVersion) bool { return v.Version == from.Version })
+	last := slices.IndexFunc(versions, func(v models.CatalogVersion) bool { return v.Version == to.Version })
+	books := trendingBooks(versions[first : last+1])
+	return &Trending{
+		From:        opts.From,
+		To:          opts.To,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Books:       books[:min(opts.Limit, len(books))],
+	}, nil
+}
+
+func (o TrendingOptions) withDefaults(now time.Time) TrendingOptions {
+	if o.To.IsZero() {
+		o.To = now
+	}
+	if o.From.IsZero() {
+		o.From = o.To.Add(-defaultTrendingRange)
+	}
+	if o.Limit == 0 {
+		o.Limit = DefaultTrendingLimit
+	}
+	return o
+}
+
+// trendingBooks ranks the books of the last version by units sold per day
+// since the first one. Books listed later in the period are measured from the
+// version they first appear in, and left out when that is the last one, so
+// their lifetime sales are not taken as sold in the period.
+func trendingBooks(versions []models.CatalogVersion) []TrendingBook {
+	from, to := versions[0], versions[len(versions)-1]
+	previousRanks := bestsellerRanks(from.Books)
+	currentRanks := bestsellerRanks(to.Books)
+
+	// baselines holds each book as first listed in the period
+	type baseline struct {
+		book models.Book
+		at   time.Time
+	}
+	baselines := make(map[uint]baseline)
+	for _, version := range versions[:len(versions)-1] {
+		for id, book := range repositories.BooksByID(version.Books) {
+			if _, ok := baselines[id]; !ok {
+				baselines[id] = baseline{book: book, at: version.FetchedAt}
+			}
+		}
+	}
+
+	trending := make([]TrendingBook, 0, len(to.Books))
+	for _, book := range repositories.BooksByID(to.Books) {
+		base, ok := baselines[book.ID]
+		if !ok {
+			continue
+		}
+		days := to.FetchedAt.Sub(base.at).Hours() / 24
+		if days <= 0 {
+			// versions fetched at the same instant give no rate
+			continue
+		}
+		sold := int64(book.UnitsSold) - int64(base.book.UnitsSold)
+
+		entry := TrendingBook{
+			ID:                     book.ID,
+			Name:                   book.Name,
+			Author:                 book.Author,
+			UnitsSold:              book.UnitsSold,
+			UnitsSoldInPeriod:      sold,
+			UnitsPerDay:            float64(sold) / days,
+			BestsellerRank:         currentRanks[book.ID],
+			PreviousBestsellerRank: previousRanks[book.ID],
+		}
+		entry.Movement = movement(entry.PreviousBestsellerRank, entry.BestsellerRank)
+		trending = append(trending, entry)
+	}
+
+	slices.SortFunc(trending, func(a, b TrendingBook) int {
+		if c := cmp.Compare(b.UnitsPerDay, a.UnitsPerDay); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	for i := range trending {
+		trending[i].Rank = i + 1
+	}
+	return trending
+}
+
+// bestsellerRanks ranks books by units sold, the lowest ID first on ties
+func bestsellerRanks(books []models.Book) map[uint]int {
+	byID := repositories.BooksByID(books)
+	ranked := make([]models.Book, 0, len(byID))
+	for _, book := range byID {
+		ranked = append(ranked, book)
+	}
+	slices.SortFunc(ranked, func(a, b models.Book) int {
+		if c := cmp.Compare(b.UnitsSold, a.UnitsSold); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	ranks := make(map[uint]int, len(ranked))
+	for i, book := range ranked {
+		ranks[book.ID] = i + 1
+	}
+	return ranks
+}
+
+func movement(previous, current int) string {
+	switch {
+	case previous == 0:
+		return MovementNew
+	case current < previous:
+		return MovementUp
+	case current > previous:
+		return MovementDown
+	}
+	return MovementSame
+}
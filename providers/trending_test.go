@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksProvider_GetTrending_OK(t *testing.T) {
+	day := 24 * time.Hour
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0: {
+			{ID: 1, Name: "Classic", UnitsSold: 10000},
+			{ID: 2, Name: "Rising", UnitsSold: 500},
+			{ID: 3, Name: "Steady", UnitsSold: 2000},
+		},
+		2 * day: {
+			{ID: 1, Name: "Classic", UnitsSold: 10020},
+			{ID: 2, Name: "Rising", UnitsSold: 1700},
+			{ID: 3, Name: "Steady", UnitsSold: 2100},
+			{ID: 4, Name: "Debut", UnitsSold: 200},
+		},
+		4 * day: {
+			{ID: 1, Name: "Classic", UnitsSold: 10040},
+			{ID: 2, Name: "Rising", UnitsSold: 2900},
+			{ID: 3, Name: "Steady", UnitsSold: 2200},
+			{ID: 4, Name: "Debut", UnitsSold: 400},
+		},
+	})
+
+	trending, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart.Add(5 * day)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, trending.FromVersion)
+	assert.Equal(t, 3, trending.ToVersion)
+	assert.Len(t, trending.Books, 4)
+
+	// velocities over the 4 days between the first and last versions: 600,
+	// 50 and 10 units per day, and 100 for Debut over the 2 days it was listed
+	assert.Equal(t, TrendingBook{
+		ID: 2, Name: "Rising", Rank: 1, UnitsSold: 2900, UnitsSoldInPeriod: 2400, UnitsPerDay: 600,
+		BestsellerRank: 2, PreviousBestsellerRank: 3, Movement: MovementUp,
+	}, trending.Books[0])
+	assert.Equal(t, uint(4), trending.Books[1].ID)
+	assert.Equal(t, MovementNew, trending.Books[1].Movement)
+	assert.Equal(t, 0, trending.Books[1].PreviousBestsellerRank)
+	assert.Equal(t, uint(3), trending.Books[2].ID)
+	assert.Equal(t, MovementDown, trending.Books[2].Movement)
+	assert.Equal(t, uint(1), trending.Books[3].ID)
+	assert.Equal(t, 10.0, trending.Books[3].UnitsPerDay)
+	assert.Equal(t, MovementSame, trending.Books[3].Movement)
+}
+
+func TestBooksProvider_GetTrending_ListedInLastVersion(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:         {{ID: 1, UnitsSold: 100}},
+		time.Hour: {{ID: 1, UnitsSold: 110}, {ID: 2, UnitsSold: 1000000}},
+	})
+
+	trending, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart.Add(time.Hour)})
+
+	// its lifetime sales were not sold in the period
+	assert.NoError(t, err)
+	assert.Len(t, trending.Books, 1)
+	assert.Equal(t, uint(1), trending.Books[0].ID)
+	assert.Equal(t, 2, trending.Books[0].BestsellerRank)
+}
+
+func TestTrendingBooks_SameInstant(t *testing.T) {
+	versions := []models.CatalogVersion{
+		{Version: 1, FetchedAt: seriesStart, Books: []models.Book{{ID: 1, UnitsSold: 100}}},
+		{Version: 2, FetchedAt: seriesStart, Books: []models.Book{{ID: 1, UnitsSold: 110}}},
+	}
+
+	books := trendingBooks(versions)
+
+	assert.Empty(t, books)
+	_, err := json.Marshal(books)
+	assert.NoError(t, err)
+}
+
+func TestBooksProvider_GetTrending_Limit(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		0:         {{ID: 1, UnitsSold: 1}, {ID: 2, UnitsSold: 1}},
+		time.Hour: {{ID: 1, UnitsSold: 5}, {ID: 2, UnitsSold: 9}},
+	})
+
+	trending, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart.Add(time.Hour), Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Len(t, trending.Books, 1)
+	assert.Equal(t, uint(2), trending.Books[0].ID)
+	assert.Equal(t, 192.0, trending.Books[0].UnitsPerDay)
+}
+
+func TestBooksProvider_GetTrending_FromBeforeHistory(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		time.Hour:     {{ID: 1, UnitsSold: 1}},
+		2 * time.Hour: {{ID: 1, UnitsSold: 2}},
+	})
+
+	trending, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart.Add(3 * time.Hour)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, trending.FromVersion)
+	assert.Equal(t, MovementSame, trending.Books[0].Movement)
+}
+
+func TestBooksProvider_GetTrending_NotEnoughHistory(t *testing.T) {
+	provider := newHistoryProvider(t, map[time.Duration][]models.Book{
+		time.Hour: {{ID: 1, UnitsSold: 1}},
+	})
+
+	_, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart.Add(2 * time.Hour)})
+	assert.ErrorIs(t, err, ErrNotEnoughHistory)
+
+	_, err = provider.GetTrending(TrendingOptions{From: seriesStart.Add(-time.Hour), To: seriesStart})
+	assert.ErrorIs(t, err, ErrNotEnoughHistory)
+
+	_, err = (&booksProvider{}).GetTrending(TrendingOptions{})
+	assert.ErrorIs(t, err, ErrNotEnoughHistory)
+}
+
+func TestBooksProvider_GetTrending_InvalidOptions(t *testing.T) {
+	provider := &booksProvider{}
+
+	_, err := provider.GetTrending(TrendingOptions{From: seriesStart, To: seriesStart})
+	assert.ErrorIs(t, err, ErrInvalidTrending)
+
+	_, err = provider.GetTrending(TrendingOptions{Limit: MaxTrendingLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidTrending)
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of upstream authentication
+const (
+	AuthNone   = "none"
+	AuthAPIKey = "api_key"
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthMTLS   = "mtls"
+	AuthOAuth2 = "oauth2"
+)
+
+// DefaultAPIKeyHeader is the header carrying the API key when none is configured
+const DefaultAPIKeyHeader = "X-API-Key"
+
+var ErrInvalidAuth = errors.New("invalid upstream authentication")
+
+// Authenticator adds credentials to the requests sent upstream
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// TransportConfigurer is implemented by authenticators that work at the
+// connection level, such as client certificates
+type TransportConfigurer interface {
+	ConfigureTransport(transport *http.Transport) error
+}
+
+// TokenInvalidator is implemented by authenticators that cache tokens, the
+// repository drops the cached token and retries once when upstream answers 401
+type TokenInvalidator interface {
+	InvalidateToken()
+}
+
+// AuthConfig represents the upstream credentials, only the fields of Type are used
+type AuthConfig struct {
+	Type      string
+	Header    string
+	Key       string
+	TokenFile string
+	Username  string
+	Password  string
+	CertFile  string
+	KeyFile   string
+	CAFile    string
+	OAuth2    ClientCredentialsConfig
+}
+
+// NewAuthenticator returns the authenticator for config, nil when Type is
+// empty or none
+func NewAuthenticator(config AuthConfig) (Authenticator, error) {
+	switch config.Type {
+	case "", AuthNone:
+		return nil, nil
+	case AuthAPIKey:
+		if config.Key == "" {
+			return nil, fmt.Errorf("%w: API key is empty", ErrInvalidAuth)
+		}
+		header := config.Header
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		return &APIKeyAuthenticator{Header: header, Key: config.Key}, nil
+	case AuthBearer:
+		if config.TokenFile == "" {
+			return nil, fmt.Errorf("%w: bearer token file is not set", ErrInvalidAuth)
+		}
+		return NewBearerTokenFileAuthenticator(config.TokenFile), nil
+	case AuthBasic:
+		if config.Username == "" {
+			return nil, fmt.Errorf("%w: username is empty", ErrInvalidAuth)
+		}
+		return &BasicAuthenticator{Username: config.Username, Password: config.Password}, nil
+	case AuthMTLS:
+		return NewClientCertAuthenticator(config.CertFile, config.KeyFile, config.CAFile)
+	case AuthOAuth2:
+		return NewClientCredentialsAuthenticator(config.OAuth2)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidAuth, config.Type)
+	}
+}
+
+// APIKeyAuthenticator sends a static key in a header
+type APIKeyAuthenticator struct {
+	Header string
+	Key    string
+}
+
+func (a *APIKeyAuthenticator) Authenticate(req *http.Request) error {
+	req.Header.Set(a.Header, a.Key)
+	return nil
+}
+
+// BasicAuthenticator sends HTTP basic auth credentials
+type BasicAuthenticator struct {
+	Username string
+	Password string
+}
+
+func (a *BasicAuthenticator) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerTokenFileAuthenticator sends the token stored in a file as a bearer
+// token. The file is read again whenever its size or modification time
+// change, so rotated tokens are picked up without a restart.
+type BearerTokenFileAuthenticator struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func NewBearerTokenFileAuthenticator(path string) *BearerTokenFileAuthenticator {
+	return &BearerTokenFileAuthenticator{path: path}
+}
+
+func (a *BearerTokenFileAuthenticator) Authenticate(req *http.Request) error {
+	token, err := a.currentToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *BearerTokenFileAuthenticator) currentToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return "", fmt.Errorf("bearer token file: %w", err)
+	}
+	if a.token != "" && info.ModTime().Equal(a.modTime) && info.Size() == a.size {
+		return a.token, nil
+	}
+
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return "", fmt.Errorf("bearer token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%w: bearer token file %s is empty", ErrInvalidAuth, a.path)
+	}
+	a.token, a.modTime, a.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// ClientCertAuthenticator presents a client certificate for mTLS. CAFile,
+// when set, replaces the system roots to verify the upstream server.
+type ClientCertAuthenticator struct {
+	certificate tls.Certificate
+	roots       *x509.CertPool
+}
+
+func NewClientCertAuthenticator(certFile, keyFile, caFile string) (*ClientCertAuthenticator, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("%w: client certificate and key files are required", ErrInvalidAuth)
+	}
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAuth, err)
+	}
+
+	authenticator := &ClientCertAuthenticator{certificate: certificate}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAuth, err)
+		}
+		authenticator.roots = x509.NewCertPool()
+		if !authenticator.roots.AppendCertsFromPEM(bytes.TrimSpace(pem)) {
+			return nil, fmt.Errorf("%w: no certificates found in %s", ErrInvalidAuth, caFile)
+		}
+	}
+	return authenticator, nil
+}
+
+// Authenticate does nothing, the certificate is presented by the transport
+func (a *ClientCertAuthenticator) Authenticate(req *http.Request) error {
+	return nil
+}
+
+func (a *ClientCertAuthenticator) ConfigureTransport(transport *http.Transport) error {
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	transport.TLSClientConfig.Certificates = []tls.Certificate{a.certificate}
+	if a.roots != nil {
+		transport.TLSClientConfig.RootCAs = a.roots
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthServer starts a TLS server answering with a single book when check
+// accepts the request and with 401 otherwise
+func newAuthServer(t *testing.T, check func(r *http.Request) bool) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !check(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1, "name": "Book 1", "author": "Author 1", "units_sold": 10, "price": 20}]`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("BOOKS_API_URL", server.URL)
+	return server
+}
+
+func TestHTTPBooksRepository_GetBooks_APIKey(t *testing.T) {
+	server := newAuthServer(t, func(r *http.Request) bool { return r.Header.Get("X-Supplier-Key") == "secret" })
+	t.Setenv("BOOKS_API_AUTH", AuthAPIKey)
+	t.Setenv("BOOKS_API_KEY_HEADER", "X-Supplier-Key")
+	t.Setenv("BOOKS_API_KEY", "secret")
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithHTTPClient(server.Client()))
+	books, err := repo.GetBooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+}
+
+func TestHTTPBooksRepository_GetBooks_Basic(t *testing.T) {
+	server := newAuthServer(t, func(r *http.Request) bool {
+		username, password, ok := r.BasicAuth()
+		return ok && username == "shop" && password == "p4ss"
+	})
+	t.Setenv("BOOKS_API_AUTH", AuthBasic)
+	t.Setenv("BOOKS_API_USERNAME", "shop")
+	t.Setenv("BOOKS_API_PASSWORD", "p4ss")
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithHTTPClient(server.Client()))
+	books, err := repo.GetBooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+}
+
+func TestHTTPBooksRepository_GetBooks_BearerTokenRotation(t *testing.T) {
+	valid := "first-token"
+	server := newAuthServer(t, func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer "+valid })
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("first-token\n"), 0o600))
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		WithHTTPClient(server.Client()),
+		WithAuthenticator(NewBearerTokenFileAuthenticator(tokenFile)),
+	)
+	books, err := repo.GetBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+
+	valid = "second-token"
+	require.NoError(t, os.WriteFile(tokenFile, []byte("second-token\n"), 0o600))
+	// make the rotation visible even on filesystems with coarse timestamps
+	require.NoError(t, os.Chtimes(tokenFile, time.Now(), time.Now().Add(time.Minute)))
+
+	books, err = repo.GetBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+}
+
+func TestBearerTokenFileAuthenticator_Errors(t *testing.T) {
+	dir := t.TempDir()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	err := NewBearerTokenFileAuthenticator(filepath.Join(dir, "missing")).Authenticate(req)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("  \n"), 0o600))
+	err = NewBearerTokenFileAuthenticator(empty).Authenticate(req)
+	assert.ErrorIs(t, err, ErrInvalidAuth)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestHTTPBooksRepository_GetBooks_MTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCA(t)
+	certFile, keyFile := writeClientCert(t, dir, ca, caKey)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "name": "Book 1"}]`))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	t.Setenv("BOOKS_API_URL", server.URL)
+
+	// the server certificate is signed by the httptest CA
+	caFile := filepath.Join(dir, "server-ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	t.Setenv("BOOKS_API_AUTH", AuthMTLS)
+	t.Setenv("BOOKS_API_CLIENT_CERT", certFile)
+	t.Setenv("BOOKS_API_CLIENT_KEY", keyFile)
+	t.Setenv("BOOKS_API_CA_FILE", caFile)
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	books, err := repo.GetBooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+
+	// without the client certificate the handshake is refused
+	repo = NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithAuthenticator(nil), WithHTTPClient(server.Client()))
+	_, err = repo.GetBooks(context.Background())
+	assert.Error(t, err)
+}
+
+func TestHTTPBooksRepository_GetBooks_InvalidAuthConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"unknown type", map[string]string{"BOOKS_API_AUTH": "kerberos"}},
+		{"api key without key", map[string]string{"BOOKS_API_AUTH": AuthAPIKey}},
+		{"bearer without file", map[string]string{"BOOKS_API_AUTH": AuthBearer}},
+		{"basic without username", map[string]string{"BOOKS_API_AUTH": AuthBasic}},
+		{"mtls with missing files", map[string]string{"BOOKS_API_AUTH": AuthMTLS, "BOOKS_API_CLIENT_CERT": "missing.pem", "BOOKS_API_CLIENT_KEY": "missing.key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BOOKS_API_URL", "https://books.invalid")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+			books, err := repo.GetBooks(context.Background())
+
+			assert.ErrorIs(t, err, ErrInvalidAuth)
+			assert.Nil(t, books)
+		})
+	}
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return ca, key
+}
+
+func writeClientCert(t *testing.T, dir string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "bookshop"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
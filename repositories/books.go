@@ -2,60 +2,313 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
+	"sync"
 	"time"
 
 	"educabot.com/bookshop/models"
 	"educabot.com/bookshop/pkg/bootstrap"
+	"educabot.com/bookshop/pkg/requestid"
 )
 
 type BooksRepository interface {
 	GetBooks(ctx context.Context) ([]models.Book, error)
 }
 
+// RateLimitReporter is implemented by repositories that rate limit their calls
+type RateLimitReporter interface {
+	RateLimitStats() []RateLimitStats
+}
+
+// MirrorReporter is implemented by repositories that hedge requests across mirrors
+type MirrorReporter interface {
+	MirrorStats() []MirrorStats
+}
+
+// DecodeReporter is implemented by repositories that decode the upstream
+// catalog leniently
+type DecodeReporter interface {
+	// LastDecodeReport returns the report of the latest decoded catalog
+	LastDecodeReport() (DecodeReport, bool)
+}
+
 type HTTPBooksRepository struct {
 	client *http.Client
-	logger *log.Logger
+	logger *slog.Logger
+	decode DecodeOptions
+	auth   Authenticator
+	limits *RateLimiters
+	// hedgeDelay is how long a request runs before the next mirror is tried
+	hedgeDelay time.Duration
+	mirrors    mirrorStats
+	// setupErr is returned by every request when the credentials or the
+	// cassette could not be set up
+	setupErr error
+
+	mu         sync.Mutex
+	lastReport *DecodeReport
+}
+
+// Option customizes an HTTPBooksRepository
+type Option func(*HTTPBooksRepository)
+
+// WithHTTPClient replaces the default client, its transport is cloned before
+// an authenticator configures it
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *HTTPBooksRepository) {
+		r.client = client
+	}
+}
+
+// WithRateLimiters replaces the limiters shared by the whole process
+func WithRateLimiters(limits *RateLimiters) Option {
+	return func(r *HTTPBooksRepository) {
+		r.limits = limits
+	}
+}
+
+// WithAuthenticator replaces the authenticator configured through bootstrap
+func WithAuthenticator(auth Authenticator) Option {
+	return func(r *HTTPBooksRepository) {
+		r.auth, r.setupErr = auth, nil
+	}
 }
 
-func NewHTTPBooksRepository(logger *log.Logger) BooksRepository {
-	return &HTTPBooksRepository{
+func NewHTTPBooksRepository(logger *slog.Logger, opts ...Option) BooksRepository {
+	r := &HTTPBooksRepository{
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		logger: logger,
+		logger:     logger,
+		hedgeDelay: bootstrap.GetBooksAPIHedgeDelay(),
+		decode: DecodeOptions{
+			BadRecords: bootstrap.GetDecodeBadRecords(),
+			MaxBytes:   bootstrap.GetBooksAPIMaxBytes(),
+			MaxItems:   bootstrap.GetBooksAPIMaxItems(),
+			MaxIssues:  DefaultMaxIssues,
+		},
+	}
+	r.auth, r.setupErr = NewAuthenticator(AuthConfig{
+		Type:      bootstrap.GetBooksAPIAuth(),
+		Header:    bootstrap.GetBooksAPIKeyHeader(),
+		Key:       bootstrap.GetBooksAPIKey(),
+		TokenFile: bootstrap.GetBooksAPITokenFile(),
+		Username:  bootstrap.GetBooksAPIUsername(),
+		Password:  bootstrap.GetBooksAPIPassword(),
+		CertFile:  bootstrap.GetBooksAPIClientCert(),
+		KeyFile:   bootstrap.GetBooksAPIClientKey(),
+		CAFile:    bootstrap.GetBooksAPICAFile(),
+		OAuth2: ClientCredentialsConfig{
+			TokenURL:      bootstrap.GetBooksAPITokenURL(),
+			ClientID:      bootstrap.GetBooksAPIClientID(),
+			ClientSecret:  bootstrap.GetBooksAPIClientSecret(),
+			Scopes:        bootstrap.GetBooksAPIScopes(),
+			RefreshBefore: bootstrap.GetBooksAPITokenRefreshBefore(),
+		},
+	})
+	limits, err := SharedRateLimiters()
+	if err != nil {
+		logger.Warn("Invalid rate limit configuration, using the defaults", "error", err)
 	}
+	r.limits = limits
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if configurer, ok := r.auth.(TransportConfigurer); ok && r.setupErr == nil {
+		r.setupErr = r.configureTransport(configurer)
+	}
+	if r.setupErr == nil {
+		r.setupErr = r.useCassette(bootstrap.GetBooksAPICassetteMode(), bootstrap.GetBooksAPICassette())
+	}
+	if r.setupErr != nil {
+		logger.Error("Error setting up the books API client", "error", r.setupErr)
+	}
+	return r
+}
+
+// useCassette records the upstream traffic to path or replays it from there,
+// depending on mode. The credential headers, including a custom API key
+// header, are redacted from recordings. Replayed requests are not
+// authenticated.
+func (r *HTTPBooksRepository) useCassette(mode, path string) error {
+	if mode == "" || mode == CassetteOff {
+		return nil
+	}
+	if path == "" {
+		return fmt.Errorf("%w: cassette path is not set", ErrInvalidCassette)
+	}
+
+	var transport http.RoundTripper
+	switch mode {
+	case CassetteRecord:
+		transport = NewRecordingTransport(r.client.Transport, path, r.decode.MaxBytes, bootstrap.GetBooksAPIKeyHeader())
+	case CassetteReplay:
+		replay, err := NewReplayTransport(path)
+		if err != nil {
+			return err
+		}
+		transport = replay
+		// replayed responses need no credentials, and fetching an OAuth2
+		// token would reach the network
+		r.auth = nil
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidCassette, mode)
+	}
+	client := *r.client
+	client.Transport = transport
+	r.client = &client
+	return nil
+}
+
+// configureTransport applies configurer to a copy of the client and its
+// transport, so clients shared with other code are left untouched
+func (r *HTTPBooksRepository) configureTransport(configurer TransportConfigurer) error {
+	transport, ok := r.client.Transport.(*http.Transport)
+	switch {
+	case r.client.Transport == nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case ok:
+		transport = transport.Clone()
+	default:
+		return fmt.Errorf("%w: cannot configure a %T transport", ErrInvalidAuth, r.client.Transport)
+	}
+	if err := configurer.ConfigureTransport(transport); err != nil {
+		return err
+	}
+	client := *r.client
+	client.Transport = transport
+	r.client = &client
+	return nil
 }
 
 func (r *HTTPBooksRepository) GetBooks(ctx context.Context) ([]models.Book, error) {
 	url := bootstrap.GetBooksAPIURL()
 
 	if url == "" {
-		r.logger.Println("BOOKS_API_URL not configured")
+		r.logger.ErrorContext(ctx, "BOOKS_API_URL not configured")
 		return nil, errors.New("API URL not configured")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		r.logger.Printf("Error creating request: %v", err)
-		return nil, errors.New("failed to create request")
+	if r.setupErr != nil {
+		return nil, r.setupErr
 	}
 
-	resp, err := r.client.Do(req)
-	if err != nil {
-		r.logger.Printf("Error making HTTP request: %v", err)
-		return nil, errors.New("failed to make HTTP request")
+	var resp *http.Response
+	var err error
+	if mirrors := bootstrap.GetBooksAPIMirrors(); len(mirrors) > 0 {
+		var release context.CancelFunc
+		resp, release, err = r.hedge(ctx, append([]string{url}, mirrors...))
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Error making HTTP request: every mirror failed", "error", err)
+			return nil, errors.New("failed to make HTTP request")
+		}
+		defer release()
+	} else if resp, err = r.send(ctx, url); err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var books []models.Book
-	if err := json.NewDecoder(resp.Body).Decode(&books); err != nil {
-		r.logger.Printf("Error decoding response: %v", err)
+	if r.decode.MaxBytes > 0 && resp.ContentLength > r.decode.MaxBytes {
+		r.logger.ErrorContext(ctx, "Response goes over the size limit", "bytes", resp.ContentLength, "limit", r.decode.MaxBytes)
+		return nil, &TooLargeError{Limit: LimitBytes, Max: r.decode.MaxBytes}
+	}
+
+	books, report, err := DecodeBooks(resp.Body, r.decode)
+	if errors.Is(err, ErrTooLarge) {
+		r.logger.ErrorContext(ctx, "Error decoding response", "error", err)
+		return nil, err
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Error decoding response", "error", err)
 		return nil, errors.New("failed to decode response")
 	}
+	if len(report.IssueCounts) > 0 {
+		r.logger.WarnContext(ctx, "Catalog records did not match the expected format",
+			"total", report.Total,
+			"coerced", report.Coerced,
+			"rejected", report.Rejected,
+			"issues", report.IssueCounts,
+		)
+	}
 
+	r.mu.Lock()
+	r.lastReport = &report
+	r.mu.Unlock()
 	return books, nil
 }
+
+func (r *HTTPBooksRepository) LastDecodeReport() (DecodeReport, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastReport == nil {
+		return DecodeReport{}, false
+	}
+	return *r.lastReport, true
+}
+
+// MirrorStats returns the stats of the primary URL and the mirrors requested so far
+func (r *HTTPBooksRepository) MirrorStats() []MirrorStats {
+	return r.mirrors.snapshot()
+}
+
+func (r *HTTPBooksRepository) RateLimitStats() []RateLimitStats {
+	return r.limits.Stats()
+}
+
+// requestSource returns the host rate limits are keyed by
+func requestSource(rawURL string) string {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	return parsed.Host
+}
+
+// send performs the GET, forwarding the request ID found in ctx, and retries
+// once with a fresh token when the upstream answers 401 and the authenticator
+// can drop its cached token
+func (r *HTTPBooksRepository) send(ctx context.Context, url string) (*http.Response, error) {
+	limiter := r.limits.Limiter(requestSource(url))
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			r.logger.ErrorContext(ctx, "Error making HTTP request", "error", err)
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Error creating request", "error", err)
+			return nil, errors.New("failed to create request")
+		}
+		if r.auth != nil {
+			if err := r.auth.Authenticate(req); err != nil {
+				r.logger.ErrorContext(ctx, "Error authenticating request", "error", err)
+				return nil, errors.New("failed to authenticate request")
+			}
+		}
+		if id := requestid.FromContext(ctx); id != "" {
+			req.Header.Set(requestid.Header, id)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Error making HTTP request", "error", err)
+			return nil, errors.New("failed to make HTTP request")
+		}
+
+		invalidator, ok := r.auth.(TokenInvalidator)
+		if resp.StatusCode != http.StatusUnauthorized || !ok || attempt > 0 {
+			return resp, nil
+		}
+		r.logger.WarnContext(ctx, "Upstream rejected the access token, retrying with a new one")
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		invalidator.InvalidateToken()
+	}
+}
@@ -1,15 +1,20 @@
 package repositories
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/requestid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,7 +35,7 @@ func TestHTTPBooksRepository_GetBooks_OK(t *testing.T) {
 	os.Setenv("BOOKS_API_URL", server.URL)
 	defer os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	assert.NoError(t, err)
@@ -49,7 +54,7 @@ func TestHTTPBooksRepository_GetBooks_ServerError(t *testing.T) {
 	os.Setenv("BOOKS_API_URL", server.URL)
 	defer os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	// The current implementation doesn't check status codes, so it will try to decode the response
@@ -68,7 +73,7 @@ func TestHTTPBooksRepository_GetBooks_InvalidJSON(t *testing.T) {
 	os.Setenv("BOOKS_API_URL", server.URL)
 	defer os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	assert.Error(t, err)
@@ -86,7 +91,7 @@ func TestHTTPBooksRepository_GetBooks_EmptyResponse(t *testing.T) {
 	os.Setenv("BOOKS_API_URL", server.URL)
 	defer os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	assert.NoError(t, err)
@@ -98,7 +103,7 @@ func TestHTTPBooksRepository_GetBooks_NetworkError(t *testing.T) {
 	os.Setenv("BOOKS_API_URL", "http://localhost:99999")
 	defer os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	assert.Error(t, err)
@@ -110,7 +115,7 @@ func TestHTTPBooksRepository_GetBooks_NoURL(t *testing.T) {
 	// Ensure no URL is set
 	os.Unsetenv("BOOKS_API_URL")
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(context.Background())
 
 	assert.Error(t, err)
@@ -134,10 +139,120 @@ func TestHTTPBooksRepository_GetBooks_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	repo := NewHTTPBooksRepository(log.New(os.Stdout, "", log.LstdFlags))
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 	books, err := repo.GetBooks(ctx)
 
 	assert.Error(t, err)
 	assert.Nil(t, books)
 	assert.Equal(t, "failed to make HTTP request", err.Error())
 }
+
+func TestHTTPBooksRepository_GetBooks_LenientDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": "1", "name": "Book 1", "price": "20", "units_sold": null}, {"id": "x"}]`))
+	}))
+	defer server.Close()
+
+	os.Setenv("BOOKS_API_URL", server.URL)
+	defer os.Unsetenv("BOOKS_API_URL")
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	_, ok := repo.(DecodeReporter).LastDecodeReport()
+	assert.False(t, ok)
+
+	books, err := repo.GetBooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Book{{ID: 1, Name: "Book 1", Price: 20}}, books)
+	report, ok := repo.(DecodeReporter).LastDecodeReport()
+	assert.True(t, ok)
+	assert.Equal(t, 1, report.Decoded)
+	assert.Equal(t, 1, report.Rejected)
+}
+
+func TestHTTPBooksRepository_GetBooks_TooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1}, {"id": 2}, {"id": 3}]`))
+	}))
+	defer server.Close()
+
+	os.Setenv("BOOKS_API_URL", server.URL)
+	defer os.Unsetenv("BOOKS_API_URL")
+
+	tests := []struct {
+		env   string
+		value string
+		limit string
+	}{
+		{"BOOKS_API_MAX_BYTES", "10", LimitBytes},
+		{"BOOKS_API_MAX_ITEMS", "2", LimitItems},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+			books, err := repo.GetBooks(context.Background())
+
+			assert.Nil(t, books)
+			var tooLarge *TooLargeError
+			assert.True(t, errors.As(err, &tooLarge))
+			assert.Equal(t, tt.limit, tooLarge.Limit)
+		})
+	}
+}
+
+func TestHTTPBooksRepository_GetBooks_RequestID(t *testing.T) {
+	var forwarded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(requestid.Header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": "x"}]`))
+	}))
+	defer server.Close()
+
+	os.Setenv("BOOKS_API_URL", server.URL)
+	defer os.Unsetenv("BOOKS_API_URL")
+
+	var logs bytes.Buffer
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&logs, nil)))
+	repo := NewHTTPBooksRepository(logger)
+	_, err := repo.GetBooks(requestid.NewContext(context.Background(), "abc-123"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-123", forwarded)
+	var record map[string]any
+	assert.NoError(t, json.NewDecoder(&logs).Decode(&record))
+	assert.Equal(t, "Catalog records did not match the expected format", record["msg"])
+	assert.Equal(t, "abc-123", record[requestid.LogKey])
+}
+
+func TestHTTPBooksRepository_GetBooks_BoundsIssues(t *testing.T) {
+	records := make([]string, 3*DefaultMaxIssues)
+	for i := range records {
+		records[i] = fmt.Sprintf(`{"id": "%d", "name": "Book", "price": 10, "units_sold": 1}`, i+1)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[" + strings.Join(records, ",") + "]"))
+	}))
+	defer server.Close()
+
+	os.Setenv("BOOKS_API_URL", server.URL)
+	defer os.Unsetenv("BOOKS_API_URL")
+
+	var logs bytes.Buffer
+	repo := NewHTTPBooksRepository(slog.New(slog.NewJSONHandler(&logs, nil)))
+	books, err := repo.GetBooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, books, len(records))
+	assert.Equal(t, 1, strings.Count(logs.String(), "\n"))
+	report, _ := repo.(DecodeReporter).LastDecodeReport()
+	assert.Len(t, report.Issues, DefaultMaxIssues)
+	assert.Equal(t, len(records)-DefaultMaxIssues, report.DroppedIssues)
+	assert.Equal(t, []IssueCount{{Field: "id", Action: RecordCoerced, Count: len(records)}}, report.IssueCounts)
+}
@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modes of the cassette transport
+const (
+	CassetteOff    = "off"
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// redacted replaces the value of credential headers in cassettes
+const redacted = "REDACTED"
+
+var (
+	ErrInvalidCassette = errors.New("invalid cassette")
+	ErrNotRecorded     = errors.New("no recorded response")
+)
+
+// credentialHeaders are never written to a cassette
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", DefaultAPIKeyHeader}
+
+// Interaction represents a request/response pair, a cassette holds one per line
+type Interaction struct {
+	RecordedAt time.Time        `json:"recorded_at"`
+	Request    RecordedRequest  `json:"request"`
+	Response   RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// RecordingTransport sends requests through next and appends every exchange
+// to a JSONL cassette, with credential headers, user info and query strings
+// redacted. Responses over maxBytes pass through without being recorded.
+type RecordingTransport struct {
+	next     http.RoundTripper
+	path     string
+	maxBytes int64
+	redact   []string
+
+	mu sync.Mutex
+}
+
+// NewRecordingTransport records to path, redacting credentialHeaders plus
+// the extra headers given, such as a custom API key header. A zero maxBytes
+// records responses of any size.
+func NewRecordingTransport(next http.RoundTripper, path string, maxBytes int64, redact ...string) *RecordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{next: next, path: path, maxBytes: maxBytes, redact: append(slices.Clone(credentialHeaders), redact...)}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body := io.Reader(resp.Body)
+	if t.maxBytes > 0 {
+		body = io.LimitReader(resp.Body, t.maxBytes+1)
+	}
+	responseBody, err := io.ReadAll(body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if t.maxBytes > 0 && int64(len(responseBody)) > t.maxBytes {
+		// the decoder reports the response as too large once it reads the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(responseBody), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		RecordedAt: time.Now().UTC(),
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL.String()),
+			Header: t.redactHeader(req.Header),
+			Body:   string(requestBody),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: t.redactHeader(resp.Header),
+			Body:   string(responseBody),
+		},
+	}
+	if err := t.append(interaction); err != nil {
+		return nil, fmt.Errorf("recording %s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return resp, nil
+}
+
+func (t *RecordingTransport) append(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	file, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (t *RecordingTransport) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	header = header.Clone()
+	for _, name := range t.redact {
+		if _, ok := header[http.CanonicalHeaderKey(name)]; ok {
+			header.Set(name, redacted)
+		}
+	}
+	return header
+}
+
+// ReplayTransport serves the responses of a cassette without touching the
+// network. Requests match on method and path, and on the query when the
+// recording kept one, so a cassette recorded against one host replays
+// against any. Matching interactions are served in
+// the order they were recorded, the last one is repeated once all were used.
+type ReplayTransport struct {
+	interactions []Interaction
+
+	mu   sync.Mutex
+	used []bool
+}
+
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{interactions: interactions, used: make([]bool, len(interactions))}, nil
+}
+
+// LoadCassette reads the interactions of a JSONL cassette, blank lines are skipped
+func LoadCassette(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCassette, err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(text, &interaction); err != nil {
+			return nil, fmt.Errorf("%w: %s line %d: %v", ErrInvalidCassette, path, line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCassette, err)
+	}
+	return interactions, nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	interaction, ok := t.match(req)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL.RequestURI())
+	}
+
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *ReplayTransport) match(req *http.Request) (Interaction, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last := -1
+	for i, interaction := range t.interactions {
+		if interaction.Request.Method != req.Method || !matchesURL(interaction.Request.URL, req.URL) {
+			continue
+		}
+		if !t.used[i] {
+			t.used[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return t.interactions[last], true
+}
+
+// matchesURL compares the path of a recorded URL with target, and the query
+// too unless it was redacted when recording
+func matchesURL(recorded string, target *url.URL) bool {
+	parsed, err := url.Parse(recorded)
+	if err != nil {
+		return false
+	}
+	if parsed.RawQuery == "" {
+		return parsed.EscapedPath() == target.EscapedPath()
+	}
+	return parsed.RequestURI() == target.RequestURI()
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCassette = "testdata/books_cassette.jsonl"
+
+func TestHTTPBooksRepository_GetBooks_RecordsCassette(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1, "name": "Book 1", "author": "Author 1", "units_sold": 10, "price": 20}]`))
+	}))
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "cassette.jsonl")
+	t.Setenv("BOOKS_API_URL", server.URL+"/books?page=1&api_key=secret")
+	t.Setenv("BOOKS_API_AUTH", AuthAPIKey)
+	t.Setenv("BOOKS_API_KEY_HEADER", "X-Supplier-Key")
+	t.Setenv("BOOKS_API_KEY", "secret")
+	t.Setenv("BOOKS_API_CASSETTE_MODE", CassetteRecord)
+	t.Setenv("BOOKS_API_CASSETTE", cassette)
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	for i := 0; i < 2; i++ {
+		books, err := repo.GetBooks(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Book 1", books[0].Name)
+	}
+
+	interactions, err := LoadCassette(cassette)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+	recorded := interactions[0]
+	assert.Equal(t, http.MethodGet, recorded.Request.Method)
+	assert.Equal(t, server.URL+"/books", recorded.Request.URL)
+	assert.Equal(t, redacted, recorded.Request.Header.Get("X-Supplier-Key"))
+	assert.Equal(t, http.StatusOK, recorded.Response.Status)
+	assert.Equal(t, redacted, recorded.Response.Header.Get("Set-Cookie"))
+	assert.Equal(t, "application/json", recorded.Response.Header.Get("Content-Type"))
+	assert.Contains(t, recorded.Response.Body, `"name": "Book 1"`)
+
+	data, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "session=abc")
+}
+
+func TestHTTPBooksRepository_GetBooks_ReplaysCassette(t *testing.T) {
+	// nothing listens on this host, every response comes from the cassette
+	t.Setenv("BOOKS_API_URL", "http://books.invalid/api/v1/books")
+	t.Setenv("BOOKS_API_CASSETTE_MODE", CassetteReplay)
+	t.Setenv("BOOKS_API_CASSETTE", testCassette)
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	books, err := repo.GetBooks(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, books, 5)
+	assert.Equal(t, models.Book{ID: 5, Name: "Learning Go", Author: "Jon Bodner", UnitsSold: 3000, Price: 35}, books[4])
+
+	t.Setenv("BOOKS_API_URL", "http://books.invalid/api/v2/books")
+	_, err = repo.GetBooks(context.Background())
+	assert.EqualError(t, err, "failed to make HTTP request")
+}
+
+func TestHTTPBooksRepository_GetBooks_ReplaysWithoutAuthenticating(t *testing.T) {
+	tokenRequests := 0
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "token", "expires_in": 3600}`))
+	}))
+	defer tokens.Close()
+	t.Setenv("BOOKS_API_URL", "http://books.invalid/api/v1/books?page=1")
+	t.Setenv("BOOKS_API_AUTH", AuthOAuth2)
+	t.Setenv("BOOKS_API_TOKEN_URL", tokens.URL)
+	t.Setenv("BOOKS_API_CLIENT_ID", "client")
+	t.Setenv("BOOKS_API_CLIENT_SECRET", "secret")
+	t.Setenv("BOOKS_API_CASSETTE_MODE", CassetteReplay)
+	t.Setenv("BOOKS_API_CASSETTE", testCassette)
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	books, err := repo.GetBooks(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, books, 5)
+	assert.Zero(t, tokenRequests)
+}
+
+func TestHTTPBooksRepository_GetBooks_RecordingTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1}, {"id": 2}, {"id": 3}]`))
+	}))
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "cassette.jsonl")
+	t.Setenv("BOOKS_API_URL", server.URL)
+	t.Setenv("BOOKS_API_MAX_BYTES", "10")
+	t.Setenv("BOOKS_API_CASSETTE_MODE", CassetteRecord)
+	t.Setenv("BOOKS_API_CASSETTE", cassette)
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	_, err := repo.GetBooks(context.Background())
+
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.NoFileExists(t, cassette)
+}
+
+func TestHTTPBooksRepository_GetBooks_InvalidCassette(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		path string
+	}{
+		{"unknown mode", "rewind", testCassette},
+		{"no path", CassetteReplay, ""},
+		{"missing file", CassetteReplay, "testdata/missing.jsonl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BOOKS_API_URL", "http://books.invalid/books")
+			t.Setenv("BOOKS_API_CASSETTE_MODE", tt.mode)
+			t.Setenv("BOOKS_API_CASSETTE", tt.path)
+
+			repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+			_, err := repo.GetBooks(context.Background())
+
+			assert.ErrorIs(t, err, ErrInvalidCassette)
+		})
+	}
+}
+
+func TestReplayTransport_ServesInRecordedOrder(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.jsonl")
+	require.NoError(t, os.WriteFile(cassette, []byte(`{"request":{"method":"GET","url":"http://a/books"},"response":{"status":200,"body":"first"}}
+
+{"request":{"method":"GET","url":"http://a/books"},"response":{"status":503,"body":"second"}}
+{"request":{"method":"POST","url":"http://a/books"},"response":{"status":201,"body":"created"}}
+`), 0o644))
+	replay, err := NewReplayTransport(cassette)
+	require.NoError(t, err)
+	client := &http.Client{Transport: replay}
+
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://b/books")
+		require.NoError(t, err)
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{200, 503, 503}, statuses)
+
+	_, err = client.Get("http://b/authors")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestLoadCassette_InvalidLine(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.jsonl")
+	require.NoError(t, os.WriteFile(cassette, []byte("{\"request\":{}}\nnot json\n"), 0o644))
+
+	_, err := LoadCassette(cassette)
+
+	assert.ErrorIs(t, err, ErrInvalidCassette)
+	assert.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,381 @@
+package repositories
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"educabot.com/bookshop/models"
+)
+
+// Policies for records that cannot be turned into a models.Book
+const (
+	// SkipBadRecords drops bad records, only the report keeps track of them
+	SkipBadRecords = "skip"
+	// QuarantineBadRecords drops bad records and keeps their raw JSON in the report
+	QuarantineBadRecords = "quarantine"
+)
+
+// Actions taken on a record field by the lenient decoder
+const (
+	RecordCoerced     = "coerced"
+	RecordSkipped     = "skipped"
+	RecordQuarantined = "quarantined"
+)
+
+var (
+	ErrNotACatalog = errors.New("response is not a JSON array of books")
+	ErrTooLarge    = errors.New("response too large")
+)
+
+// Limits enforced by DecodeOptions
+const (
+	LimitBytes = "bytes"
+	LimitItems = "items"
+)
+
+// TooLargeError is returned when a response goes over one of the decode
+// limits. It matches ErrTooLarge with errors.Is.
+type TooLargeError struct {
+	Limit string
+	Max   int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("response too large: more than %d %s", e.Max, e.Limit)
+}
+
+func (e *TooLargeError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// DefaultMaxIssues is how many record issues the repository keeps per decode
+const DefaultMaxIssues = 100
+
+// DecodeOptions configures DecodeBooks. Zero limits are not enforced.
+type DecodeOptions struct {
+	// BadRecords is SkipBadRecords or QuarantineBadRecords
+	BadRecords string
+	MaxBytes   int64
+	MaxItems   int
+	// MaxIssues bounds the issues and the quarantined records kept one by
+	// one, the rest are only counted
+	MaxIssues int
+}
+
+// RecordIssue represents a field of an upstream record that did not match
+// models.Book. Coerced fields were converted and the record was kept.
+type RecordIssue struct {
+	Index   int    `json:"index" example:"3"`
+	Field   string `json:"field" example:"price"`
+	Value   string `json:"value" example:"\"45\""`
+	Message string `json:"message" example:"string converted to number"`
+	Action  string `json:"action" example:"coerced"`
+}
+
+// IssueCount represents how many record issues share a field and an action
+type IssueCount struct {
+	Field  string `json:"field" example:"price"`
+	Action string `json:"action" example:"coerced"`
+	Count  int    `json:"count" example:"12"`
+}
+
+// DecodeReport represents the outcome of decoding an upstream catalog.
+// Issues holds the first MaxIssues issues, IssueCounts counts all of them.
+// Quarantined holds the first MaxIssues quarantined records.
+type DecodeReport struct {
+	Total         int               `json:"total" example:"50"`
+	Decoded       int               `json:"decoded" example:"49"`
+	Coerced       int               `json:"coerced" example:"12"`
+	Rejected      int               `json:"rejected" example:"1"`
+	Issues        []RecordIssue     `json:"issues"`
+	DroppedIssues int               `json:"dropped_issues" example:"0"`
+	IssueCounts   []IssueCount      `json:"issue_counts"`
+	Quarantined   []json.RawMessage `json:"quarantined,omitempty" swaggertype:"array,object"`
+
+	DroppedQuarantined int `json:"dropped_quarantined,omitempty" example:"0"`
+}
+
+// add keeps issues within max and counts them by field and action
+func (r *DecodeReport) add(issues []RecordIssue, max int, counts map[IssueCount]int) {
+	for _, issue := range issues {
+		counts[IssueCount{Field: issue.Field, Action: issue.Action}]++
+		if max > 0 && len(r.Issues) >= max {
+			r.DroppedIssues++
+			continue
+		}
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// countIssues returns counts ordered by field and action
+func countIssues(counts map[IssueCount]int) []IssueCount {
+	summary := make([]IssueCount, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		summary = append(summary, key)
+	}
+	slices.SortFunc(summary, func(a, b IssueCount) int {
+		return cmp.Or(cmp.Compare(a.Field, b.Field), cmp.Compare(a.Action, b.Action))
+	})
+	return summary
+}
+
+// DecodeBooks decodes a JSON array of books one record at a time, coercing
+// the known upstream variants: numbers sent as strings or floats, null
+// numbers and differently cased keys such as "unitsSold". Records that still
+// do not fit are handled according to BadRecords instead of failing the whole
+// catalog. Only a body that is not a JSON array or goes over the limits
+// returns an error.
+func DecodeBooks(r io.Reader, opts DecodeOptions) ([]models.Book, DecodeReport, error) {
+	report := DecodeReport{Issues: []RecordIssue{}, IssueCounts: []IssueCount{}}
+	counts := make(map[IssueCount]int)
+	if opts.MaxBytes > 0 {
+		r = &limitedReader{r: r, max: opts.MaxBytes}
+	}
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, report, err
+	}
+
+	books := []models.Book{}
+	for index := 0; dec.More(); index++ {
+		if opts.MaxItems > 0 && index >= opts.MaxItems {
+			return nil, report, &TooLargeError{Limit: LimitItems, Max: int64(opts.MaxItems)}
+		}
+		var record json.RawMessage
+		if err := dec.Decode(&record); err != nil {
+			return nil, report, decodeError(err)
+		}
+
+		report.Total++
+		book, issues, ok := decodeBook(index, record)
+		if !ok {
+			action := RecordSkipped
+			if opts.BadRecords == QuarantineBadRecords {
+				action = RecordQuarantined
+				if opts.MaxIssues > 0 && len(report.Quarantined) >= opts.MaxIssues {
+					report.DroppedQuarantined++
+				} else {
+					report.Quarantined = append(report.Quarantined, record)
+				}
+			}
+			for i := range issues {
+				if issues[i].Action != RecordCoerced {
+					issues[i].Action = action
+				}
+			}
+			report.Rejected++
+			report.add(issues, opts.MaxIssues, counts)
+			continue
+		}
+		if len(issues) > 0 {
+			report.Coerced++
+			report.add(issues, opts.MaxIssues, counts)
+		}
+		report.Decoded++
+		books = append(books, book)
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, report, err
+	}
+	report.IssueCounts = countIssues(counts)
+	return books, report, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return decodeError(err)
+	}
+	if token != delim {
+		return fmt.Errorf("%w: unexpected %v", ErrNotACatalog, token)
+	}
+	return nil
+}
+
+// decodeError keeps limit errors as they are and reports anything else as a
+// malformed catalog
+func decodeError(err error) error {
+	if errors.Is(err, ErrTooLarge) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrNotACatalog, err)
+}
+
+// bookFields maps the normalized upstream keys to the models.Book fields
+var bookFields = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"author":    "author",
+	"unitssold": "units_sold",
+	"price":     "price",
+}
+
+// decodeBook returns false when the record cannot be used, the issues that
+// caused it have no action yet
+func decodeBook(index int, record json.RawMessage) (models.Book, []RecordIssue, bool) {
+	var book models.Book
+	var issues []RecordIssue
+	issue := func(field string, value json.RawMessage, message, action string) {
+		issues = append(issues, RecordIssue{Index: index, Field: field, Value: string(value), Message: message, Action: action})
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(record, &raw); err != nil || raw == nil {
+		issue("", record, "record is not an object", "")
+		return book, issues, false
+	}
+	fields := make(map[string]json.RawMessage, len(raw))
+	for key, value := range raw {
+		if field, ok := bookFields[normalizeKey(key)]; ok {
+			fields[field] = value
+		}
+	}
+
+	ok := true
+	for _, field := range []string{"id", "units_sold", "price"} {
+		value, present := fields[field]
+		n, message, err := coerceUint(value, present, field != "id")
+		if err != nil {
+			issue(field, value, err.Error(), "")
+			ok = false
+			continue
+		}
+		if message != "" {
+			issue(field, value, message, RecordCoerced)
+		}
+		switch field {
+		case "id":
+			book.ID = n
+		case "units_sold":
+			book.UnitsSold = n
+		case "price":
+			book.Price = n
+		}
+	}
+	for _, field := range []string{"name", "author"} {
+		value := fields[field]
+		s, message := coerceString(value)
+		if message != "" {
+			issue(field, value, message, RecordCoerced)
+		}
+		if field == "name" {
+			book.Name = s
+		} else {
+			book.Author = s
+		}
+	}
+	return book, issues, ok
+}
+
+var keySeparators = strings.NewReplacer("_", "", "-", "", " ", "")
+
+// normalizeKey folds "units_sold", "unitsSold" and "Units-Sold" into the same key
+func normalizeKey(key string) string {
+	return keySeparators.Replace(strings.ToLower(key))
+}
+
+// coerceUint returns a non-empty message when value had to be converted.
+// Missing and null numbers are read as zero and decimals are rounded, unless
+// lenient is off as for IDs, which must be present whole numbers.
+func coerceUint(value json.RawMessage, present, lenient bool) (uint, string, error) {
+	value = bytes.TrimSpace(value)
+	if !present || string(value) == "null" {
+		switch {
+		case !lenient:
+			return 0, "", errors.New("missing value")
+		case !present:
+			return 0, "missing value read as 0", nil
+		}
+		return 0, "null read as 0", nil
+	}
+
+	text := string(value)
+	message := ""
+	if value[0] == '"' {
+		if err := json.Unmarshal(value, &text); err != nil {
+			return 0, "", errors.New("invalid string")
+		}
+		text = strings.TrimSpace(text)
+		message = "string converted to number"
+	}
+
+	if n, err := strconv.ParseUint(text, 10, 0); err == nil {
+		return uint(n), message, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	switch {
+	case err != nil || math.IsNaN(f) || math.IsInf(f, 0):
+		return 0, "", errors.New("not a number")
+	case f < 0:
+		return 0, "", errors.New("negative number")
+	// float64(math.MaxUint64) rounds up to 2^64, which no uint can hold
+	case f >= math.MaxUint64:
+		return 0, "", errors.New("number out of range")
+	}
+	if f != math.Trunc(f) {
+		if !lenient {
+			return 0, "", errors.New("not a whole number")
+		}
+		if message == "" {
+			message = "decimal rounded to the nearest integer"
+		} else {
+			message = "decimal string rounded to the nearest integer"
+		}
+	} else if message == "" {
+		message = "float converted to integer"
+	}
+	return uint(math.Round(f)), message, nil
+}
+
+// coerceString keeps strings as they are, reads null as empty and writes
+// numbers and booleans as their JSON text
+func coerceString(value json.RawMessage) (string, string) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return "", ""
+	}
+	if string(value) == "null" {
+		return "", "null read as empty string"
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, ""
+	}
+	return string(value), "value converted to string"
+}
+
+// limitedReader fails with a TooLargeError once more than max bytes are
+// read, unlike io.LimitReader which ends the stream silently
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.read > l.max {
+		return 0, &TooLargeError{Limit: LimitBytes, Max: l.max}
+	}
+	if left := l.max - l.read + 1; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		// the byte past the limit is held back so the decoder cannot finish
+		// a value with it
+		return n - 1, &TooLargeError{Limit: LimitBytes, Max: l.max}
+	}
+	return n, err
+}
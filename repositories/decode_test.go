@@ -0,0 +1,307 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBooks_CoercesKnownVariants(t *testing.T) {
+	body := `[
+		{"id": "1", "name": "Strings", "author": "A", "units_sold": "100", "price": "45"},
+		{"id": 2, "name": "Floats", "author": "B", "units_sold": 200.0, "price": 39.6},
+		{"id": 3, "name": "Nulls", "author": null, "units_sold": null, "price": 10},
+		{"ID": 4, "Name": "Camel case", "Author": "C", "unitsSold": 5, "Price": 7}
+	]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: SkipBadRecords})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Book{
+		{ID: 1, Name: "Strings", Author: "A", UnitsSold: 100, Price: 45},
+		{ID: 2, Name: "Floats", Author: "B", UnitsSold: 200, Price: 40},
+		{ID: 3, Name: "Nulls", Author: "", UnitsSold: 0, Price: 10},
+		{ID: 4, Name: "Camel case", Author: "C", UnitsSold: 5, Price: 7},
+	}, books)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 4, report.Decoded)
+	assert.Equal(t, 3, report.Coerced)
+	assert.Equal(t, 0, report.Rejected)
+	assert.Equal(t, []RecordIssue{
+		{Index: 0, Field: "id", Value: `"1"`, Message: "string converted to number", Action: RecordCoerced},
+		{Index: 0, Field: "units_sold", Value: `"100"`, Message: "string converted to number", Action: RecordCoerced},
+		{Index: 0, Field: "price", Value: `"45"`, Message: "string converted to number", Action: RecordCoerced},
+		{Index: 1, Field: "units_sold", Value: "200.0", Message: "float converted to integer", Action: RecordCoerced},
+		{Index: 1, Field: "price", Value: "39.6", Message: "decimal rounded to the nearest integer", Action: RecordCoerced},
+		{Index: 2, Field: "units_sold", Value: "null", Message: "null read as 0", Action: RecordCoerced},
+		{Index: 2, Field: "author", Value: "null", Message: "null read as empty string", Action: RecordCoerced},
+	}, report.Issues)
+}
+
+func TestDecodeBooks_SkipsBadRecords(t *testing.T) {
+	body := `[
+		{"id": 1, "name": "Good", "price": 10, "units_sold": 1},
+		{"id": "abc", "name": "Bad ID", "price": 10},
+		{"id": 3, "name": "Negative", "price": -5, "units_sold": "many"},
+		"not an object",
+		{"name": "No ID", "price": 10},
+		{"id": 6.5, "name": "Fractional ID"}
+	]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: SkipBadRecords})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Book{{ID: 1, Name: "Good", Price: 10, UnitsSold: 1}}, books)
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 1, report.Decoded)
+	assert.Equal(t, 5, report.Rejected)
+	assert.Nil(t, report.Quarantined)
+
+	type failure struct {
+		Index   int
+		Field   string
+		Message string
+	}
+	var failures []failure
+	for _, issue := range report.Issues {
+		if issue.Action == RecordSkipped {
+			failures = append(failures, failure{issue.Index, issue.Field, issue.Message})
+		}
+	}
+	assert.Equal(t, []failure{
+		{1, "id", "not a number"},
+		{2, "units_sold", "not a number"},
+		{2, "price", "negative number"},
+		{3, "", "record is not an object"},
+		{4, "id", "missing value"},
+		{5, "id", "not a whole number"},
+	}, failures)
+}
+
+func TestDecodeBooks_OutOfRange(t *testing.T) {
+	body := `[{"id": 1, "price": 1.8446744073709552e19}, {"id": 2, "price": "18446744073709551616"}, {"id": 3, "price": 1.8446744073709550e19}]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: SkipBadRecords})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Book{{ID: 3, Price: 18446744073709549568}}, books)
+	assert.Equal(t, 2, report.Rejected)
+	for _, issue := range report.Issues {
+		if issue.Field == "price" && issue.Action == RecordSkipped {
+			assert.Equal(t, "number out of range", issue.Message)
+		}
+	}
+}
+
+func TestDecodeBooks_QuarantinesBadRecords(t *testing.T) {
+	body := `[{"id": 1, "price": 10, "units_sold": 1}, {"id": true}]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: QuarantineBadRecords})
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"id": true}`)}, report.Quarantined)
+	assert.Equal(t, RecordQuarantined, report.Issues[0].Action)
+}
+
+func TestDecodeBooks_MaxQuarantined(t *testing.T) {
+	body := `[{"id": true}, {"id": false}, {"id": 1, "price": 10, "units_sold": 1}, {"id": []}]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: QuarantineBadRecords, MaxIssues: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, 3, report.Rejected)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"id": true}`), json.RawMessage(`{"id": false}`)}, report.Quarantined)
+	assert.Equal(t, 1, report.DroppedQuarantined)
+}
+
+func TestDecodeBooks_MaxIssues(t *testing.T) {
+	body := `[{"id": "1", "price": "10"}, {"id": "2", "price": "20"}, {"id": "x"}]`
+
+	books, report, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: SkipBadRecords, MaxIssues: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Len(t, report.Issues, 2)
+	assert.Equal(t, 7, report.DroppedIssues)
+	assert.Equal(t, []IssueCount{
+		{Field: "id", Action: RecordCoerced, Count: 2},
+		{Field: "id", Action: RecordSkipped, Count: 1},
+		{Field: "price", Action: RecordCoerced, Count: 3},
+		{Field: "units_sold", Action: RecordCoerced, Count: 3},
+	}, report.IssueCounts)
+}
+
+func TestDecodeBooks_NotACatalog(t *testing.T) {
+	for _, body := range []string{"invalid json", `{"id": 1}`, ""} {
+		books, _, err := DecodeBooks(strings.NewReader(body), DecodeOptions{BadRecords: SkipBadRecords})
+
+		assert.ErrorIs(t, err, ErrNotACatalog, body)
+		assert.Nil(t, books)
+	}
+}
+
+func TestDecodeBooks_MaxItems(t *testing.T) {
+	body := `[{"id": 1}, {"id": 2}, {"id": 3}]`
+
+	books, _, err := DecodeBooks(strings.NewReader(body), DecodeOptions{MaxItems: 3})
+	assert.NoError(t, err)
+	assert.Len(t, books, 3)
+
+	books, _, err = DecodeBooks(strings.NewReader(body), DecodeOptions{MaxItems: 2})
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.Nil(t, books)
+
+	var tooLarge *TooLargeError
+	assert.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, &TooLargeError{Limit: LimitItems, Max: 2}, tooLarge)
+	assert.Equal(t, "response too large: more than 2 items", err.Error())
+}
+
+func TestDecodeBooks_MaxBytes(t *testing.T) {
+	body := `[{"id": 1}, {"id": 2}]`
+
+	_, _, err := DecodeBooks(strings.NewReader(body), DecodeOptions{MaxBytes: int64(len(body))})
+	assert.NoError(t, err)
+
+	books, _, err := DecodeBooks(strings.NewReader(body), DecodeOptions{MaxBytes: int64(len(body)) - 1})
+	assert.Nil(t, books)
+	var tooLarge *TooLargeError
+	assert.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, &TooLargeError{Limit: LimitBytes, Max: int64(len(body)) - 1}, tooLarge)
+}
+
+func TestDecodeBooks_StopsReadingAtTheLimit(t *testing.T) {
+	catalog := newCatalogReader(1_000_000)
+
+	_, _, err := DecodeBooks(catalog, DecodeOptions{MaxBytes: 1 << 20})
+
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.LessOrEqual(t, catalog.read, int64(1<<20)+1)
+}
+
+// catalogReader generates a JSON array of n books without holding it in memory
+type catalogReader struct {
+	n, next int
+	read    int64
+	pending []byte
+	closed  bool
+}
+
+func newCatalogReader(n int) *catalogReader {
+	return &catalogReader{n: n, pending: []byte("[")}
+}
+
+func (c *catalogReader) Read(p []byte) (int, error) {
+	for len(c.pending) < len(p) && !c.closed {
+		if c.next == c.n {
+			c.pending = append(c.pending, ']')
+			c.closed = true
+			break
+		}
+		if c.next > 0 {
+			c.pending = append(c.pending, ',')
+		}
+		c.pending = fmt.Appendf(c.pending, `{"id":%d,"name":"Book %d","author":"Author %d","units_sold":%d,"price":%d}`,
+			c.next+1, c.next, c.next%1000, c.next*7%100000, 10+c.next%90)
+		c.next++
+	}
+	if len(c.pending) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	c.read += int64(n)
+	return n, nil
+}
+
+// peakHeap runs f while sampling the heap and reports the highest live heap
+// seen, in MB, which B/op does not show
+func peakHeap(b *testing.B, f func()) {
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var peak atomic.Uint64
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var stats runtime.MemStats
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak.Load() {
+					peak.Store(stats.HeapAlloc)
+				}
+			}
+		}
+	}()
+
+	f()
+	close(done)
+	<-sampled
+	if peak.Load() > before.HeapAlloc {
+		b.ReportMetric(float64(peak.Load()-before.HeapAlloc)/(1<<20), "peak-MB")
+	}
+}
+
+// BenchmarkDecode_1M compares the previous whole-body decoding with the
+// streaming one on a 1M books payload, go test -bench Decode_1M -run ^$ ./repositories/
+func BenchmarkDecode_1M(b *testing.B) {
+	const items = 1_000_000
+
+	b.Run("whole body", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			peakHeap(b, func() {
+				body, err := io.ReadAll(newCatalogReader(items))
+				if err != nil {
+					b.Fatal(err)
+				}
+				var books []models.Book
+				if err := json.Unmarshal(body, &books); err != nil {
+					b.Fatal(err)
+				}
+			})
+		}
+	})
+
+	b.Run("streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			peakHeap(b, func() {
+				if _, _, err := DecodeBooks(newCatalogReader(items), DecodeOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			})
+		}
+	})
+
+	b.Run("streaming over limit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			peakHeap(b, func() {
+				_, _, err := DecodeBooks(newCatalogReader(items), DecodeOptions{MaxItems: items / 10})
+				if !errors.Is(err, ErrTooLarge) {
+					b.Fatal(err)
+				}
+			})
+		}
+	})
+}
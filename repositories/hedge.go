@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// mirrorLatencyWindow is how many recent latencies the mirror percentiles are computed over
+const mirrorLatencyWindow = 256
+
+// MirrorStats represents the hedged requests sent to an upstream URL.
+// Latencies are measured until the response headers arrive, over the
+// successful responses only.
+type MirrorStats struct {
+	URL        string        `json:"url" example:"https://mirror-1.example.com/books"`
+	Requests   int64         `json:"requests" example:"120"`
+	Wins       int64         `json:"wins" example:"97"`
+	Failures   int64         `json:"failures" example:"2"`
+	Cancelled  int64         `json:"cancelled" example:"21"`
+	LatencyP50 time.Duration `json:"latency_p50_ns" swaggertype:"integer" example:"85000000"`
+	LatencyP99 time.Duration `json:"latency_p99_ns" swaggertype:"integer" example:"410000000"`
+	LatencyMax time.Duration `json:"latency_max_ns" swaggertype:"integer" example:"900000000"`
+
+	latencies []time.Duration
+}
+
+// mirrorStats keeps the stats of every URL requested by hedge
+type mirrorStats struct {
+	mu    sync.Mutex
+	order []string
+	stats map[string]*MirrorStats
+}
+
+func (m *mirrorStats) update(url string, f func(*MirrorStats)) {
+	url = redactURL(url)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stats == nil {
+		m.stats = map[string]*MirrorStats{}
+	}
+	stats, ok := m.stats[url]
+	if !ok {
+		stats = &MirrorStats{URL: url}
+		m.stats[url] = stats
+		m.order = append(m.order, url)
+	}
+	f(stats)
+}
+
+// snapshot returns the stats in the order the URLs were first requested
+func (m *mirrorStats) snapshot() []MirrorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make([]MirrorStats, 0, len(m.order))
+	for _, url := range m.order {
+		stats := *m.stats[url]
+		if len(stats.latencies) > 0 {
+			sorted := slices.Clone(stats.latencies)
+			slices.Sort(sorted)
+			stats.LatencyP50 = sorted[(len(sorted)-1)*50/100]
+			stats.LatencyP99 = sorted[(len(sorted)-1)*99/100]
+		}
+		stats.latencies = nil
+		snapshot = append(snapshot, stats)
+	}
+	return snapshot
+}
+
+func (s *MirrorStats) observe(latency time.Duration) {
+	s.latencies = append(s.latencies, latency)
+	if len(s.latencies) > mirrorLatencyWindow {
+		s.latencies = slices.Delete(s.latencies, 0, len(s.latencies)-mirrorLatencyWindow)
+	}
+	s.LatencyMax = max(s.LatencyMax, latency)
+}
+
+// redactURL drops credentials and query parameters, which may hold API keys
+func redactURL(rawURL string) string {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "invalid URL"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	return parsed.String()
+}
+
+type hedgeResult struct {
+	index   int
+	resp    *http.Response
+	err     error
+	latency time.Duration
+}
+
+// hedge sends the request to urls[0] and, whenever the last request sent has
+// not answered within the hedge delay or has failed, to the next URL. The
+// first 2xx response wins and the other requests are cancelled. The returned
+// release function cancels the context of the winner once its body is read.
+func (r *HTTPBooksRepository) hedge(ctx context.Context, urls []string) (*http.Response, context.CancelFunc, error) {
+	results := make(chan hedgeResult, len(urls))
+	cancels := make([]context.CancelFunc, 0, len(urls))
+	launch := func() {
+		index := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		r.mirrors.update(urls[index], func(s *MirrorStats) { s.Requests++ })
+
+		go func() {
+			start := time.Now()
+			resp, err := r.send(attemptCtx, urls[index])
+			if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				err = fmt.Errorf("upstream answered %d", resp.StatusCode)
+			}
+			results <- hedgeResult{index: index, resp: resp, err: err, latency: time.Since(start)}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			if len(cancels) < len(urls) {
+				launch()
+				pending++
+				timer.Reset(r.hedgeDelay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				r.mirrors.update(urls[result.index], func(s *MirrorStats) {
+					s.Wins++
+					s.observe(result.latency)
+				})
+				for i, cancel := range cancels {
+					if i != result.index {
+						cancel()
+					}
+				}
+				go r.drainHedged(urls, results, pending)
+				return result.resp, cancels[result.index], nil
+			}
+
+			lastErr = result.err
+			r.logger.WarnContext(ctx, "Mirror request failed", "url", redactURL(urls[result.index]), "error", result.err)
+			r.mirrors.update(urls[result.index], func(s *MirrorStats) { s.Failures++ })
+			if len(cancels) < len(urls) {
+				launch()
+				pending++
+				timer.Reset(r.hedgeDelay)
+			}
+		}
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return nil, func() {}, lastErr
+}
+
+// drainHedged waits for the requests that lost the race, closing any late
+// response and counting them as cancelled
+func (r *HTTPBooksRepository) drainHedged(urls []string, results <-chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.resp != nil {
+			result.resp.Body.Close()
+		}
+		r.mirrors.update(urls[result.index], func(s *MirrorStats) { s.Cancelled++ })
+	}
+}
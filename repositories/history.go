@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/models"
+)
+
+// HistoryRetention bounds how many catalog versions are kept. Every version
+// holds a full copy of its catalog, MaxBooks bounds the books stored across
+// all of them. Zero values disable the corresponding limit and the latest
+// version is always kept.
+type HistoryRetention struct {
+	MaxVersions int
+	MaxAge      time.Duration
+	MaxBooks    int
+}
+
+type HistoryRepository interface {
+	// Record stores a fetched catalog and returns the version it belongs to.
+	// Catalogs fetched before the latest one recorded are stale and ignored,
+	// so versions stay in time order when fetches overlap.
+	Record(books []models.Book, at time.Time) models.CatalogVersion
+	// Versions returns the retained catalog versions, oldest first
+	Versions() []models.CatalogVersion
+	// BookChanges returns the retained changes of a book, oldest first
+	BookChanges(id uint) []models.BookChange
+}
+
+type InMemoryHistoryRepository struct {
+	mu        sync.RWMutex
+	retention HistoryRetention
+	versions  []models.CatalogVersion
+	changes   map[uint][]models.BookChange
+	next      int
+}
+
+func NewInMemoryHistoryRepository(retention HistoryRetention) *InMemoryHistoryRepository {
+	return &InMemoryHistoryRepository{
+		retention: retention,
+		changes:   make(map[uint][]models.BookChange),
+		next:      1,
+	}
+}
+
+func (r *InMemoryHistoryRepository) Record(books []models.Book, at time.Time) models.CatalogVersion {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var previous []models.Book
+	if len(r.versions) > 0 {
+		latest := r.versions[len(r.versions)-1]
+		if at.Before(latest.LastSeenAt) {
+			return latest
+		}
+		previous = latest.Books
+	}
+
+	changes := diffCatalogs(previous, books)
+	if len(r.versions) > 0 && len(changes) == 0 {
+		// Compact fetches that did not change anything into the latest version
+		latest := &r.versions[len(r.versions)-1]
+		latest.LastSeenAt = at
+		r.applyRetention(at)
+		return r.versions[len(r.versions)-1]
+	}
+
+	version := models.CatalogVersion{
+		Version:    r.next,
+		FetchedAt:  at,
+		LastSeenAt: at,
+		Books:      slices.Clone(books),
+	}
+	r.next++
+	r.versions = append(r.versions, version)
+
+	for _, change := range changes {
+		change.Version = version.Version
+		change.ChangedAt = at
+		id := bookChangeID(change)
+		r.changes[id] = append(r.changes[id], change)
+	}
+
+	r.applyRetention(at)
+	return version
+}
+
+func (r *InMemoryHistoryRepository) Versions() []models.CatalogVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.versions)
+}
+
+func (r *InMemoryHistoryRepository) BookChanges(id uint) []models.BookChange {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.changes[id])
+}
+
+// applyRetention drops the oldest versions over the limits, along with the
+// changes that led to them. The books of the oldest version left are recorded
+// as added in it, so books that did not change since keep their history.
+func (r *InMemoryHistoryRepository) applyRetention(now time.Time) {
+	drop := 0
+	if r.retention.MaxVersions > 0 && len(r.versions) > r.retention.MaxVersions {
+		drop = len(r.versions) - r.retention.MaxVersions
+	}
+	if r.retention.MaxAge > 0 {
+		cutoff := now.Add(-r.retention.MaxAge)
+		for drop < len(r.versions)-1 && r.versions[drop].LastSeenAt.Before(cutoff) {
+			drop++
+		}
+	}
+	if r.retention.MaxBooks > 0 {
+		stored := 0
+		for _, version := range r.versions[drop:] {
+			stored += len(version.Books)
+		}
+		for drop < len(r.versions)-1 && stored > r.retention.MaxBooks {
+			stored -= len(r.versions[drop].Books)
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+
+	r.versions = slices.Delete(r.versions, 0, drop)
+	baseline := r.versions[0]
+	books := BooksByID(baseline.Books)
+	for id, changes := range r.changes {
+		changes = slices.DeleteFunc(changes, func(change models.BookChange) bool {
+			return change.Version <= baseline.Version
+		})
+		if book, ok := books[id]; ok {
+			changes = slices.Insert(changes, 0, models.BookChange{
+				Version:   baseline.Version,
+				ChangedAt: baseline.FetchedAt,
+				Type:      models.BookAdded,
+				After:     &book,
+			})
+		}
+		if len(changes) == 0 {
+			delete(r.changes, id)
+			continue
+		}
+		r.changes[id] = changes
+	}
+}
+
+// diffCatalogs returns the changes between two catalogs ordered by book ID.
+// When a catalog repeats an ID the last book wins.
+func diffCatalogs(previous, current []models.Book) []models.BookChange {
+	before := BooksByID(previous)
+	after := BooksByID(current)
+
+	var changes []models.BookChange
+	for id, book := range after {
+		old, ok := before[id]
+		switch {
+		case !ok:
+			changes = append(changes, models.BookChange{Type: models.BookAdded, After: &book})
+		case old != book:
+			changes = append(changes, models.BookChange{Type: models.BookUpdated, Before: &old, After: &book})
+		}
+	}
+	for id, book := range before {
+		if _, ok := after[id]; !ok {
+			changes = append(changes, models.BookChange{Type: models.BookRemoved, Before: &book})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b models.BookChange) int {
+		return cmp.Compare(bookChangeID(a), bookChangeID(b))
+	})
+	return changes
+}
+
+// BooksByID indexes books by ID, the last book wins when an ID is repeated
+func BooksByID(books []models.Book) map[uint]models.Book {
+	byID := make(map[uint]models.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+	return byID
+}
+
+func bookChangeID(change models.BookChange) uint {
+	if change.After != nil {
+		return change.After.ID
+	}
+	return change.Before.ID
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var historyStart = time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func TestInMemoryHistoryRepository_Record(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{})
+
+	v1 := repo.Record([]models.Book{
+		{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20},
+		{ID: 2, Name: "Book 2", UnitsSold: 200, Price: 30},
+	}, historyStart)
+	v2 := repo.Record([]models.Book{
+		{ID: 1, Name: "Book 1", UnitsSold: 150, Price: 25},
+		{ID: 3, Name: "Book 3", UnitsSold: 10, Price: 15},
+	}, historyStart.Add(time.Hour))
+
+	assert.Equal(t, 1, v1.Version)
+	assert.Equal(t, 2, v2.Version)
+	assert.Len(t, repo.Versions(), 2)
+
+	changes := repo.BookChanges(1)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, models.BookAdded, changes[0].Type)
+	assert.Nil(t, changes[0].Before)
+	assert.Equal(t, models.BookUpdated, changes[1].Type)
+	assert.Equal(t, uint(20), changes[1].Before.Price)
+	assert.Equal(t, uint(25), changes[1].After.Price)
+	assert.Equal(t, historyStart.Add(time.Hour), changes[1].ChangedAt)
+
+	changes = repo.BookChanges(2)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, models.BookRemoved, changes[1].Type)
+	assert.Nil(t, changes[1].After)
+
+	assert.Len(t, repo.BookChanges(3), 1)
+	assert.Empty(t, repo.BookChanges(4))
+}
+
+func TestInMemoryHistoryRepository_CompactsUnchangedFetches(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{})
+	books := []models.Book{{ID: 1, Name: "Book 1", UnitsSold: 100, Price: 20}}
+
+	repo.Record(books, historyStart)
+	version := repo.Record([]models.Book{books[0]}, historyStart.Add(time.Hour))
+
+	assert.Equal(t, 1, version.Version)
+	assert.Equal(t, historyStart, version.FetchedAt)
+	assert.Equal(t, historyStart.Add(time.Hour), version.LastSeenAt)
+	assert.Len(t, repo.Versions(), 1)
+	assert.Len(t, repo.BookChanges(1), 1)
+}
+
+func TestInMemoryHistoryRepository_RecordsEmptyFirstCatalog(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{})
+
+	version := repo.Record(nil, historyStart)
+	repo.Record(nil, historyStart.Add(time.Hour))
+
+	assert.Equal(t, 1, version.Version)
+	assert.Len(t, repo.Versions(), 1)
+}
+
+func TestInMemoryHistoryRepository_MaxVersions(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{MaxVersions: 2})
+
+	for i := uint(1); i <= 4; i++ {
+		repo.Record([]models.Book{{ID: 1, UnitsSold: i * 100}}, historyStart.Add(time.Duration(i)*time.Hour))
+	}
+
+	versions := repo.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, 4, versions[1].Version)
+
+	changes := repo.BookChanges(1)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, 3, changes[0].Version)
+	assert.Equal(t, models.BookAdded, changes[0].Type)
+}
+
+func TestInMemoryHistoryRepository_RetentionKeepsUnchangedBooks(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{MaxVersions: 2})
+
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}, {ID: 2, UnitsSold: 100}}, historyStart)
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}, {ID: 2, UnitsSold: 200}}, historyStart.Add(time.Hour))
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}, {ID: 2, UnitsSold: 300}}, historyStart.Add(2*time.Hour))
+
+	versions := repo.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+
+	// book 1 was added in the dropped version and never changed since
+	changes := repo.BookChanges(1)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, models.BookAdded, changes[0].Type)
+	assert.Equal(t, 2, changes[0].Version)
+	assert.Equal(t, historyStart.Add(time.Hour), changes[0].ChangedAt)
+	assert.Equal(t, uint(100), changes[0].After.UnitsSold)
+
+	changes = repo.BookChanges(2)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, models.BookAdded, changes[0].Type)
+	assert.Equal(t, uint(200), changes[0].After.UnitsSold)
+	assert.Equal(t, models.BookUpdated, changes[1].Type)
+	assert.Equal(t, 3, changes[1].Version)
+}
+
+func TestInMemoryHistoryRepository_MaxAge(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{MaxAge: 36 * time.Hour})
+
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}}, historyStart)
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 200}}, historyStart.Add(24*time.Hour))
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 300}}, historyStart.Add(48*time.Hour))
+
+	versions := repo.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+
+	// the latest version is kept however old it is
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 300}}, historyStart.Add(240*time.Hour))
+	versions = repo.Versions()
+	assert.Len(t, versions, 1)
+	assert.Equal(t, 3, versions[0].Version)
+}
+
+func TestInMemoryHistoryRepository_MaxBooks(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{MaxBooks: 3})
+
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}, {ID: 2}}, historyStart)
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 200}}, historyStart.Add(time.Hour))
+	assert.Len(t, repo.Versions(), 2)
+
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 300}, {ID: 3}}, historyStart.Add(2*time.Hour))
+	versions := repo.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+
+	// the latest version is kept even when it alone is over the limit
+	repo.Record([]models.Book{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}, historyStart.Add(3*time.Hour))
+	versions = repo.Versions()
+	assert.Len(t, versions, 1)
+	assert.Equal(t, 4, versions[0].Version)
+}
+
+func TestInMemoryHistoryRepository_IgnoresStaleCatalogs(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{})
+
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 100}}, historyStart)
+	repo.Record([]models.Book{{ID: 1, UnitsSold: 300}}, historyStart.Add(2*time.Hour))
+	version := repo.Record([]models.Book{{ID: 1, UnitsSold: 200}}, historyStart.Add(time.Hour))
+
+	assert.Equal(t, 2, version.Version)
+	versions := repo.Versions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, uint(300), versions[1].Books[0].UnitsSold)
+	assert.Len(t, repo.BookChanges(1), 2)
+}
+
+func TestInMemoryHistoryRepository_ReturnsCopies(t *testing.T) {
+	repo := NewInMemoryHistoryRepository(HistoryRetention{})
+	books := []models.Book{{ID: 1, Name: "Book 1"}}
+	repo.Record(books, historyStart)
+
+	books[0].Name = "Changed"
+	versions := repo.Versions()
+	versions[0].Version = 42
+
+	assert.Equal(t, "Book 1", repo.Versions()[0].Books[0].Name)
+	assert.Equal(t, 1, repo.Versions()[0].Version)
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/metrics"
+)
+
+// Reasons upstream errors are counted under
+const (
+	UpstreamErrorCanceled    = "canceled"
+	UpstreamErrorRateLimited = "rate_limited"
+	UpstreamErrorTooLarge    = "too_large"
+	UpstreamErrorOther       = "other"
+)
+
+// InstrumentedBooksRepository records the latency and errors of the calls to
+// the wrapped repository and the size of the last catalog it returned. The
+// reports of the wrapped repository are passed through.
+type InstrumentedBooksRepository struct {
+	next     BooksRepository
+	duration *metrics.HistogramVec
+	errors   *metrics.CounterVec
+	size     *metrics.Gauge
+}
+
+func NewInstrumentedBooksRepository(next BooksRepository, registry *metrics.Registry) BooksRepository {
+	return &InstrumentedBooksRepository{
+		next: next,
+		duration: metrics.NewHistogramVec(registry, "books_api_request_duration_seconds",
+			"Latency of the calls to the books API.", nil, "outcome"),
+		errors: metrics.NewCounterVec(registry, "books_api_errors_total",
+			"Failed calls to the books API.", "reason"),
+		size: metrics.NewGauge(registry, "books_catalog_size",
+			"Books in the last catalog fetched from the books API."),
+	}
+}
+
+func (r *InstrumentedBooksRepository) GetBooks(ctx context.Context) ([]models.Book, error) {
+	start := time.Now()
+	books, err := r.next.GetBooks(ctx)
+	if err != nil {
+		r.duration.Observe(time.Since(start).Seconds(), "error")
+		r.errors.Inc(upstreamErrorReason(ctx, err))
+		return nil, err
+	}
+	r.duration.Observe(time.Since(start).Seconds(), "success")
+	r.size.Set(float64(len(books)))
+	return books, nil
+}
+
+// upstreamErrorReason classifies err, the repositories hide most causes
+// behind generic errors so only the known ones get a reason of their own
+func upstreamErrorReason(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return UpstreamErrorRateLimited
+	case errors.Is(err, ErrTooLarge):
+		return UpstreamErrorTooLarge
+	case ctx.Err() != nil:
+		return UpstreamErrorCanceled
+	}
+	return UpstreamErrorOther
+}
+
+func (r *InstrumentedBooksRepository) LastDecodeReport() (DecodeReport, bool) {
+	if reporter, ok := r.next.(DecodeReporter); ok {
+		return reporter.LastDecodeReport()
+	}
+	return DecodeReport{}, false
+}
+
+func (r *InstrumentedBooksRepository) RateLimitStats() []RateLimitStats {
+	if reporter, ok := r.next.(RateLimitReporter); ok {
+		return reporter.RateLimitStats()
+	}
+	return []RateLimitStats{}
+}
+
+func (r *InstrumentedBooksRepository) MirrorStats() []MirrorStats {
+	if reporter, ok := r.next.(MirrorReporter); ok {
+		return reporter.MirrorStats()
+	}
+	return []MirrorStats{}
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubBooksRepository struct {
+	books []models.Book
+	err   error
+}
+
+func (s *stubBooksRepository) GetBooks(ctx context.Context) ([]models.Book, error) {
+	return s.books, s.err
+}
+
+func TestInstrumentedBooksRepository_GetBooks(t *testing.T) {
+	registry := metrics.NewRegistry()
+	stub := &stubBooksRepository{books: []models.Book{{ID: 1}, {ID: 2}}}
+	repo := NewInstrumentedBooksRepository(stub, registry)
+
+	books, err := repo.GetBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+
+	stub.err = fmt.Errorf("%w for books.example.com", ErrRateLimited)
+	_, err = repo.GetBooks(context.Background())
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	stub.err = errors.New("failed to make HTTP request")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.GetBooks(ctx)
+	assert.Error(t, err)
+
+	var b strings.Builder
+	registry.WriteTo(&b)
+	exposition := b.String()
+	assert.Contains(t, exposition, `books_api_request_duration_seconds_count{outcome="success"} 1`)
+	assert.Contains(t, exposition, `books_api_request_duration_seconds_count{outcome="error"} 2`)
+	assert.Contains(t, exposition, `books_api_errors_total{reason="rate_limited"} 1`)
+	assert.Contains(t, exposition, `books_api_errors_total{reason="canceled"} 1`)
+	assert.Contains(t, exposition, "books_catalog_size 2\n")
+}
+
+func TestInstrumentedBooksRepository_Reports(t *testing.T) {
+	repo := NewInstrumentedBooksRepository(&stubBooksRepository{}, metrics.NewRegistry())
+
+	_, ok := repo.(DecodeReporter).LastDecodeReport()
+	assert.False(t, ok)
+	assert.Empty(t, repo.(RateLimitReporter).RateLimitStats())
+	assert.Empty(t, repo.(MirrorReporter).MirrorStats())
+
+	limits := NewRateLimiters(RateLimit{Rate: 1, Burst: 1}, nil, RateLimitWait)
+	limits.Limiter("books.example.com")
+	repo = NewInstrumentedBooksRepository(NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithRateLimiters(limits)), metrics.NewRegistry())
+	assert.Len(t, repo.(RateLimitReporter).RateLimitStats(), 1)
+}
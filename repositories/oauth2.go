@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshBefore is how long before expiring a token is renewed
+// when no margin is configured
+const DefaultTokenRefreshBefore = time.Minute
+
+// ClientCredentialsConfig configures the OAuth2 client credentials grant
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RefreshBefore renews tokens this long before they expire, capped at
+	// half their lifetime
+	RefreshBefore time.Duration
+	// HTTPClient requests the tokens, a client with a 10 second timeout is used when nil
+	HTTPClient *http.Client
+}
+
+// ClientCredentialsAuthenticator sends bearer tokens obtained from an OAuth2
+// authorization server with the client credentials grant. Tokens are cached
+// until shortly before they expire or until upstream rejects them.
+type ClientCredentialsAuthenticator struct {
+	config ClientCredentialsConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func NewClientCredentialsAuthenticator(config ClientCredentialsConfig) (*ClientCredentialsAuthenticator, error) {
+	if config.TokenURL == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("%w: OAuth2 token URL and client ID are required", ErrInvalidAuth)
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = DefaultTokenRefreshBefore
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ClientCredentialsAuthenticator{config: config, now: time.Now}, nil
+}
+
+func (a *ClientCredentialsAuthenticator) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == "" || (!a.refreshAt.IsZero() && !a.now().Before(a.refreshAt)) {
+		if err := a.fetchToken(req); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// InvalidateToken drops the cached token so the next request fetches a new one
+func (a *ClientCredentialsAuthenticator) InvalidateToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetchToken runs the grant with the context of req, so cancelling the books
+// request also cancels the token request
+func (a *ClientCredentialsAuthenticator) fetchToken(req *http.Request) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.config.Scopes, " "))
+	}
+	tokenReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, a.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating token request: %w", err)
+	}
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenReq.Header.Set("Accept", "application/json")
+	tokenReq.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+
+	issuedAt := a.now()
+	resp, err := a.config.HTTPClient.Do(tokenReq)
+	if err != nil {
+		return fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("decoding token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token endpoint answered %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token endpoint answered without access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	a.token = token.AccessToken
+	a.refreshAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		a.refreshAt = issuedAt.Add(max(lifetime-a.config.RefreshBefore, lifetime/2))
+	}
+	return nil
+}
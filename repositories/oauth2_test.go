@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer acts as an OAuth2 authorization server issuing token-1,
+// token-2... valid for expiresIn seconds
+type tokenServer struct {
+	*httptest.Server
+	issued    atomic.Int32
+	expiresIn int
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	ts := &tokenServer{expiresIn: expiresIn}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+		if !ok || clientID != "bookshop" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad credentials"})
+			return
+		}
+		assert.Equal(t, "catalog:read stock:read", r.FormValue("scope"))
+		n := ts.issued.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   ts.expiresIn,
+		})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func clientCredentials(ts *tokenServer) ClientCredentialsConfig {
+	return ClientCredentialsConfig{
+		TokenURL:      ts.URL,
+		ClientID:      "bookshop",
+		ClientSecret:  "s3cret",
+		Scopes:        []string{"catalog:read", "stock:read"},
+		RefreshBefore: time.Minute,
+	}
+}
+
+func TestClientCredentialsAuthenticator_CachesAndRefreshesEarly(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	auth, err := NewClientCredentialsAuthenticator(clientCredentials(ts))
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+
+	authorization := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/books", nil)
+		require.NoError(t, auth.Authenticate(req))
+		return req.Header.Get("Authorization")
+	}
+
+	assert.Equal(t, "Bearer token-1", authorization())
+	now = now.Add(58 * time.Minute)
+	assert.Equal(t, "Bearer token-1", authorization())
+
+	// one minute before expiring the token is renewed
+	now = now.Add(time.Minute)
+	assert.Equal(t, "Bearer token-2", authorization())
+	assert.Equal(t, int32(2), ts.issued.Load())
+}
+
+func TestClientCredentialsAuthenticator_ShortLivedTokens(t *testing.T) {
+	ts := newTokenServer(t, 30)
+	auth, err := NewClientCredentialsAuthenticator(clientCredentials(ts))
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+
+	// the margin is capped at half the lifetime so the token is still reused
+	require.NoError(t, auth.Authenticate(req))
+	now = now.Add(10 * time.Second)
+	require.NoError(t, auth.Authenticate(req))
+	assert.Equal(t, int32(1), ts.issued.Load())
+
+	now = now.Add(5 * time.Second)
+	require.NoError(t, auth.Authenticate(req))
+	assert.Equal(t, int32(2), ts.issued.Load())
+}
+
+func TestClientCredentialsAuthenticator_Errors(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	config := clientCredentials(ts)
+	config.ClientSecret = "wrong"
+	auth, err := NewClientCredentialsAuthenticator(config)
+	require.NoError(t, err)
+
+	err = auth.Authenticate(httptest.NewRequest(http.MethodGet, "/books", nil))
+	assert.ErrorContains(t, err, "token endpoint answered 401: invalid_client bad credentials")
+
+	_, err = NewClientCredentialsAuthenticator(ClientCredentialsConfig{ClientID: "bookshop"})
+	assert.ErrorIs(t, err, ErrInvalidAuth)
+}
+
+func TestHTTPBooksRepository_GetBooks_OAuth2RetriesOnce(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	var requests atomic.Int32
+	// the books API revokes token-1 after issuing it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "name": "Book 1"}]`))
+	}))
+	defer server.Close()
+	t.Setenv("BOOKS_API_URL", server.URL)
+	t.Setenv("BOOKS_API_AUTH", AuthOAuth2)
+	t.Setenv("BOOKS_API_TOKEN_URL", ts.URL)
+	t.Setenv("BOOKS_API_CLIENT_ID", "bookshop")
+	t.Setenv("BOOKS_API_CLIENT_SECRET", "s3cret")
+	t.Setenv("BOOKS_API_SCOPES", "catalog:read, stock:read")
+
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	books, err := repo.GetBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// the new token is cached
+	_, err = repo.GetBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int32(2), ts.issued.Load())
+}
+
+func TestHTTPBooksRepository_GetBooks_OAuth2GivesUpAfterRetry(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	t.Setenv("BOOKS_API_URL", server.URL)
+
+	auth, err := NewClientCredentialsAuthenticator(clientCredentials(ts))
+	require.NoError(t, err)
+	repo := NewHTTPBooksRepository(slog.New(slog.NewTextHandler(os.Stdout, nil)), WithAuthenticator(auth))
+
+	_, err = repo.GetBooks(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(2), ts.issued.Load())
+}
@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/pkg/bootstrap"
+)
+
+// What a RateLimiter does when no token is available
+const (
+	// RateLimitWait blocks until a token is available or the request context ends
+	RateLimitWait = "wait"
+	// RateLimitFail rejects the call right away
+	RateLimitFail = "fail"
+)
+
+var (
+	ErrRateLimited       = errors.New("upstream rate limit reached")
+	ErrInvalidRateLimits = errors.New("invalid rate limits")
+)
+
+// RateLimit represents a token bucket refilled with Rate tokens per second up
+// to Burst tokens. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitStats represents the outgoing calls seen by the limiter of a source
+type RateLimitStats struct {
+	Source    string        `json:"source" example:"6781a8a5f9b1c2d3e4f5a6b7.mockapi.io"`
+	Rate      float64       `json:"rate" example:"5"`
+	Burst     int           `json:"burst" example:"10"`
+	Allowed   int64         `json:"allowed" example:"120"`
+	Throttled int64         `json:"throttled" example:"7"`
+	Rejected  int64         `json:"rejected" example:"2"`
+	Waited    time.Duration `json:"waited_ns" swaggertype:"integer" example:"1500000000"`
+}
+
+// RateLimiter is a token bucket for a single source
+type RateLimiter struct {
+	limit  RateLimit
+	policy string
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	stats  RateLimitStats
+}
+
+func NewRateLimiter(source string, limit RateLimit, policy string) *RateLimiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &RateLimiter{
+		limit:  limit,
+		policy: policy,
+		now:    time.Now,
+		tokens: float64(limit.Burst),
+		stats:  RateLimitStats{Source: source, Rate: limit.Rate, Burst: limit.Burst},
+	}
+}
+
+// Wait takes a token, waiting for one under RateLimitWait. It gives up
+// early with ErrRateLimited when ctx ends before the token would be ready.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.limit.Rate <= 0 {
+		l.mu.Lock()
+		l.stats.Allowed++
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		l.stats.Allowed++
+		l.mu.Unlock()
+		return nil
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.limit.Rate * float64(time.Second))
+	deadline, hasDeadline := ctx.Deadline()
+	if l.policy != RateLimitWait || (hasDeadline && deadline.Before(now.Add(delay))) {
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return fmt.Errorf("%w for %s, next call allowed in %s", ErrRateLimited, l.stats.Source, delay.Round(time.Millisecond))
+	}
+	// the token is reserved now so concurrent callers queue behind it
+	l.tokens--
+	l.stats.Throttled++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		l.mu.Lock()
+		l.stats.Allowed++
+		l.stats.Waited += delay
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return fmt.Errorf("%w for %s: %w", ErrRateLimited, l.stats.Source, ctx.Err())
+	}
+}
+
+// Stats returns the calls seen so far
+func (l *RateLimiter) Stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens = min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limit.Rate)
+	}
+	l.last = now
+}
+
+// RateLimiters hands out one limiter per source, usually the upstream host,
+// so every fetcher calling the same source shares its quota
+type RateLimiters struct {
+	defaultLimit RateLimit
+	sourceLimits map[string]RateLimit
+	policy       string
+
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+}
+
+// NewRateLimiters applies sourceLimits to the sources they name and
+// defaultLimit to every other one
+func NewRateLimiters(defaultLimit RateLimit, sourceLimits map[string]RateLimit, policy string) *RateLimiters {
+	return &RateLimiters{
+		defaultLimit: defaultLimit,
+		sourceLimits: sourceLimits,
+		policy:       policy,
+		limiters:     map[string]*RateLimiter{},
+	}
+}
+
+// Limiter returns the limiter of source, creating it on first use
+func (r *RateLimiters) Limiter(source string) *RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limiter, ok := r.limiters[source]
+	if !ok {
+		limit, ok := r.sourceLimits[source]
+		if !ok {
+			limit = r.defaultLimit
+		}
+		limiter = NewRateLimiter(source, limit, r.policy)
+		r.limiters[source] = limiter
+	}
+	return limiter
+}
+
+// Stats returns the stats of every source called so far, ordered by source
+func (r *RateLimiters) Stats() []RateLimitStats {
+	r.mu.Lock()
+	limiters := make([]*RateLimiter, 0, len(r.limiters))
+	for _, limiter := range r.limiters {
+		limiters = append(limiters, limiter)
+	}
+	r.mu.Unlock()
+
+	stats := make([]RateLimitStats, len(limiters))
+	for i, limiter := range limiters {
+		stats[i] = limiter.Stats()
+	}
+	slices.SortFunc(stats, func(a, b RateLimitStats) int { return strings.Compare(a.Source, b.Source) })
+	return stats
+}
+
+// sharedRateLimiters is built from bootstrap once, so every repository and
+// fetcher of the process draws from the same buckets
+var sharedRateLimiters = sync.OnceValues(configuredRateLimiters)
+
+// configuredRateLimiters builds the limiters configured through bootstrap. An
+// unknown policy falls back to RateLimitWait and invalid per-source limits to
+// the default limit, both are reported in the error.
+func configuredRateLimiters() (*RateLimiters, error) {
+	defaultLimit := RateLimit{Rate: bootstrap.GetBooksAPIRateLimit(), Burst: bootstrap.GetBooksAPIRateBurst()}
+	sourceLimits, err := ParseSourceLimits(bootstrap.GetBooksAPISourceRateLimits())
+	policy := bootstrap.GetBooksAPIRateLimitPolicy()
+	if policy != RateLimitWait && policy != RateLimitFail {
+		err = errors.Join(err, fmt.Errorf("%w: unknown policy %q", ErrInvalidRateLimits, policy))
+		policy = RateLimitWait
+	}
+	return NewRateLimiters(defaultLimit, sourceLimits, policy), err
+}
+
+// SharedRateLimiters returns the process wide limiters configured through
+// bootstrap, along with the error found in their configuration
+func SharedRateLimiters() (*RateLimiters, error) {
+	return sharedRateLimiters()
+}
+
+// ParseSourceLimits parses "host=rate:burst" entries such as
+// "api.partner.com=2:5", the burst defaults to 1 when omitted
+func ParseSourceLimits(entries []string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(entries))
+	for _, entry := range entries {
+		source, value, ok := strings.Cut(entry, "=")
+		if !ok || source == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRateLimits, entry)
+		}
+		rate, burst, _ := strings.Cut(value, ":")
+		limit := RateLimit{Burst: 1}
+		var err error
+		if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.Rate < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRateLimits, entry)
+		}
+		if burst != "" {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRateLimits, entry)
+			}
+		}
+		limits[strings.TrimSpace(source)] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_BurstThenFail(t *testing.T) {
+	limiter := NewRateLimiter("api", RateLimit{Rate: 1, Burst: 2}, RateLimitFail)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.NoError(t, limiter.Wait(context.Background()))
+	err := limiter.Wait(context.Background())
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.EqualError(t, err, "upstream rate limit reached for api, next call allowed in 1s")
+
+	now = now.Add(time.Second)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	// the bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.ErrorIs(t, limiter.Wait(context.Background()), ErrRateLimited)
+
+	assert.Equal(t, RateLimitStats{Source: "api", Rate: 1, Burst: 2, Allowed: 5, Rejected: 2}, limiter.Stats())
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := NewRateLimiter("api", RateLimit{Rate: 50, Burst: 1}, RateLimitWait)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+	stats := limiter.Stats()
+	assert.Equal(t, int64(3), stats.Allowed)
+	assert.Equal(t, int64(2), stats.Throttled)
+	assert.Positive(t, stats.Waited)
+}
+
+func TestRateLimiter_WaitRespectsContext(t *testing.T) {
+	limiter := NewRateLimiter("api", RateLimit{Rate: 0.1, Burst: 1}, RateLimitWait)
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	// the next token is 10s away, past the deadline, so there is no point in waiting
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, limiter.Wait(ctx), ErrRateLimited)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// a cancelled wait gives its reserved token back
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := limiter.Wait(ctx)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.InDelta(t, 0, limiter.tokens, 0.01)
+	assert.Equal(t, int64(2), limiter.Stats().Rejected)
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	limiter := NewRateLimiter("api", RateLimit{}, RateLimitFail)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.Equal(t, int64(100), limiter.Stats().Allowed)
+}
+
+func TestRateLimiters_PerSource(t *testing.T) {
+	limits := NewRateLimiters(RateLimit{Rate: 1, Burst: 1}, map[string]RateLimit{"partner.com": {Rate: 10, Burst: 3}}, RateLimitFail)
+
+	assert.Same(t, limits.Limiter("partner.com"), limits.Limiter("partner.com"))
+	assert.Equal(t, RateLimit{Rate: 10, Burst: 3}, limits.Limiter("partner.com").limit)
+	assert.Equal(t, RateLimit{Rate: 1, Burst: 1}, limits.Limiter("mockapi.io").limit)
+	assert.Equal(t, []string{"mockapi.io", "partner.com"}, []string{limits.Stats()[0].Source, limits.Stats()[1].Source})
+}
+
+func TestParseSourceLimits(t *testing.T) {
+	limits, err := ParseSourceLimits([]string{"partner.com=2:5", "mockapi.io=0.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]RateLimit{
+		"partner.com": {Rate: 2, Burst: 5},
+		"mockapi.io":  {Rate: 0.5, Burst: 1},
+	}, limits)
+
+	for _, entry := range []string{"partner.com", "=1", "a=x", "a=-1", "a=1:0", "a=1:x"} {
+		_, err := ParseSourceLimits([]string{entry})
+		assert.ErrorIs(t, err, ErrInvalidRateLimits, entry)
+	}
+}
+
+func TestConfiguredRateLimiters_Policy(t *testing.T) {
+	t.Setenv("BOOKS_API_RATE_LIMIT", "0.001")
+	t.Setenv("BOOKS_API_RATE_LIMIT_POLICY", RateLimitFail)
+	limits, err := configuredRateLimiters()
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitFail, limits.policy)
+
+	t.Setenv("BOOKS_API_RATE_LIMIT_POLICY", "wiat")
+	limits, err = configuredRateLimiters()
+	assert.ErrorIs(t, err, ErrInvalidRateLimits)
+	assert.EqualError(t, err, `invalid rate limits: unknown policy "wiat"`)
+	assert.Equal(t, RateLimitWait, limits.policy)
+}
+
+func TestHTTPBooksRepository_GetBooks_RateLimited(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	t.Setenv("BOOKS_API_URL", server.URL)
+
+	limits := NewRateLimiters(RateLimit{Rate: 0.001, Burst: 2}, nil, RateLimitFail)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// two repositories sharing the limiters draw from the same bucket
+	first := NewHTTPBooksRepository(logger, WithRateLimiters(limits))
+	second := NewHTTPBooksRepository(logger, WithRateLimiters(limits))
+
+	_, err := first.GetBooks(context.Background())
+	assert.NoError(t, err)
+	_, err = second.GetBooks(context.Background())
+	assert.NoError(t, err)
+	_, err = first.GetBooks(context.Background())
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	assert.Equal(t, 2, requests)
+	stats := first.(RateLimitReporter).RateLimitStats()
+	assert.Equal(t, server.Listener.Addr().String(), stats[0].Source)
+	assert.Equal(t, int64(2), stats[0].Allowed)
+	assert.Equal(t, int64(1), stats[0].Rejected)
+}